@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	parameters "github.com/BakedSoftware/go-parameters"
+)
+
+const parametersPath = "github.com/BakedSoftware/go-parameters"
+
+type generator struct {
+	warn func(msg string)
+
+	buf     bytes.Buffer
+	pkg     *types.Package
+	targets map[string]bool
+	imports map[string]bool
+}
+
+// binding describes how a field of a given type is read from the params
+type binding struct {
+	getter string // Params method returning (value, ok)
+	kind   string // type reported in a TypeError
+	conv   string // format applied to the getter value before assignment
+}
+
+// load type checks the package in dir, skipping the file named skip which is
+// usually the previous output of the generator
+func (g *generator) load(dir, skip string) (*types.Package, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	files := make([]*ast.File, 0, len(bp.GoFiles))
+	for _, name := range bp.GoFiles {
+		if name == skip {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		// Errors are tolerated so code calling BindParams still type checks
+		// enough to regenerate when the output file is missing
+		Error: func(error) {},
+	}
+	pkg, _ := conf.Check(bp.Name, fset, files, nil)
+	return pkg, nil
+}
+
+// generate returns the formatted source of the BindParams methods for the
+// named struct types of pkg
+func (g *generator) generate(pkg *types.Package, names []string) ([]byte, error) {
+	g.pkg = pkg
+	g.targets = make(map[string]bool, len(names))
+	g.imports = map[string]bool{parametersPath: true}
+	for _, name := range names {
+		g.targets[name] = true
+	}
+
+	var body bytes.Buffer
+	for _, name := range names {
+		obj := pkg.Scope().Lookup(name)
+		if obj == nil {
+			return nil, fmt.Errorf("type %s not found in package %s", name, pkg.Name())
+		}
+		st, ok := obj.Type().Underlying().(*types.Struct)
+		if !ok {
+			return nil, fmt.Errorf("type %s is not a struct", name)
+		}
+		g.buf.Reset()
+		g.bindStruct(name, st)
+		body.Write(g.buf.Bytes())
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by \"paramgen -type=%s\"; DO NOT EDIT.\n\n", strings.Join(names, ","))
+	fmt.Fprintf(&src, "package %s\n\n", pkg.Name())
+	src.WriteString("import (\n")
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		if path != parametersPath {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Fprintf(&src, "\t%q\n", path)
+	}
+	if len(paths) > 0 {
+		src.WriteString("\n")
+	}
+	fmt.Fprintf(&src, "\tparameters %q\n", parametersPath)
+	src.WriteString(")\n")
+	src.Write(body.Bytes())
+
+	return format.Source(src.Bytes())
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// field is a struct field to bind along with its default tag
+type field struct {
+	*types.Var
+	def    string
+	hasDef bool
+}
+
+func (g *generator) bindStruct(name string, st *types.Struct) {
+	recv := strings.ToLower(name[:1])
+	if recv == "p" || recv == "v" || recv == "m" {
+		recv = "x"
+	}
+	defaults := strings.ToLower(name[:1]) + name[1:] + "ParamDefaults"
+
+	var body bytes.Buffer
+	var defs []string
+	for _, f := range fields(st) {
+		key, ok := g.bindField(&body, recv, name, defaults, f)
+		if ok && f.hasDef {
+			defs = append(defs, fmt.Sprintf("%q: %s", key, defaultLiteral(f.def)))
+		}
+	}
+
+	if len(defs) > 0 {
+		g.printf("\nvar %s = &parameters.Params{Values: map[string]interface{}{\n", defaults)
+		for _, def := range defs {
+			g.printf("\t%s,\n", def)
+		}
+		g.printf("}}\n")
+	}
+	g.printf("\n// BindParams sets the fields of %s from p without reflection\n", recv)
+	g.printf("func (%s *%s) BindParams(p *parameters.Params) error {\n", recv, name)
+	g.buf.Write(body.Bytes())
+	g.printf("\treturn nil\n}\n")
+}
+
+// defaultLiteral converts a default tag like Imbue does for form values
+func defaultLiteral(def string) string {
+	switch strings.ToLower(def) {
+	case "true":
+		return "true"
+	case "false":
+		return "false"
+	}
+	return fmt.Sprintf("%q", def)
+}
+
+// fields lists the exported fields of st including the ones promoted from
+// embedded structs, shallower fields hiding deeper ones like the selector
+// rules do
+func fields(st *types.Struct) []field {
+	seen := make(map[string]bool)
+	var list []field
+	var embedded []*types.Struct
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if f.Embedded() {
+			if sub, ok := f.Type().Underlying().(*types.Struct); ok {
+				embedded = append(embedded, sub)
+				continue
+			}
+		}
+		if f.Exported() {
+			seen[f.Name()] = true
+			def, hasDef := reflect.StructTag(st.Tag(i)).Lookup("default")
+			list = append(list, field{Var: f, def: def, hasDef: hasDef})
+		}
+	}
+	for _, sub := range embedded {
+		for _, f := range fields(sub) {
+			if !seen[f.Name()] {
+				seen[f.Name()] = true
+				list = append(list, f)
+			}
+		}
+	}
+	return list
+}
+
+// bindField writes the binding of f to w and returns the key it is bound
+// from. Fields with a default tag fall back to the defaults params when their
+// key is absent.
+func (g *generator) bindField(w *bytes.Buffer, recv, typeName, defaults string, f field) (string, bool) {
+	printf := func(format string, args ...interface{}) {
+		fmt.Fprintf(w, format, args...)
+	}
+
+	key := parameters.CamelToSnakeCase(f.Name())
+	if parameters.SnakeToCamelCase(key, true) != f.Name() {
+		g.warn(fmt.Sprintf("%s.%s: key %q does not map back to the field, skipped", typeName, f.Name(), key))
+		return "", false
+	}
+
+	if b, ok := g.binding(f.Type()); ok {
+		if f.hasDef && b.getter == "GetBytesOk" {
+			// GetBytesOk caches the decoded value which would race on the
+			// shared defaults
+			g.warn(fmt.Sprintf("%s.%s: default is not supported for bytes, ignored", typeName, f.Name()))
+			f.hasDef = false
+		}
+		printf("\tif v, ok := p.%s(%q); ok {\n", b.getter, key)
+		printf("\t\t%s.%s = %s\n", recv, f.Name(), fmt.Sprintf(b.conv, "v"))
+		printf("\t} else if _, present := p.Get(%q); present {\n", key)
+		printf("\t\treturn &parameters.TypeError{Key: %q, Type: %q}\n", key, b.kind)
+		if f.hasDef {
+			printf("\t} else if v, ok := %s.%s(%q); ok {\n", defaults, b.getter, key)
+			printf("\t\t%s.%s = %s\n", recv, f.Name(), fmt.Sprintf(b.conv, "v"))
+		}
+		printf("\t}\n")
+		return key, f.hasDef
+	}
+
+	if _, ok := f.Type().Underlying().(*types.Struct); ok && g.local(f.Type()) {
+		bindStruct := func() {
+			printf("\t\tvar v %s\n", types.TypeString(f.Type(), types.RelativeTo(g.pkg)))
+			if named, ok := f.Type().(*types.Named); ok && g.targets[named.Obj().Name()] {
+				printf("\t\tif err := v.BindParams(&parameters.Params{Values: m}); err != nil {\n")
+				printf("\t\t\treturn err\n")
+				printf("\t\t}\n")
+			} else {
+				printf("\t\t(&parameters.Params{Values: m}).Imbue(&v)\n")
+			}
+			printf("\t\t%s.%s = v\n", recv, f.Name())
+		}
+
+		printf("\tif m, ok := p.GetJSONOk(%q); ok {\n", key)
+		bindStruct()
+		printf("\t} else if _, present := p.Get(%q); present {\n", key)
+		printf("\t\treturn &parameters.TypeError{Key: %q, Type: %q}\n", key, "object")
+		if f.hasDef {
+			printf("\t} else if m, ok := %s.GetJSONOk(%q); ok {\n", defaults, key)
+			bindStruct()
+		}
+		printf("\t}\n")
+		return key, f.hasDef
+	}
+
+	g.warn(fmt.Sprintf("%s.%s: unsupported type %s, skipped", typeName, f.Name(), f.Type()))
+	return "", false
+}
+
+// local reports if t can be spelled in the generated file without further
+// imports
+func (g *generator) local(t types.Type) bool {
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj().Pkg() == g.pkg
+	}
+	return true
+}
+
+func (g *generator) binding(t types.Type) (binding, bool) {
+	switch types.TypeString(t, nil) {
+	case "time.Time":
+		return binding{"GetTimeOk", "time", "%s"}, true
+	case "*time.Time":
+		return binding{"GetTimeOk", "time", "&%s"}, true
+	case "*mime/multipart.FileHeader":
+		return binding{"GetFileOk", "file", "%s"}, true
+	case "[]byte", "[]uint8":
+		return binding{"GetBytesOk", "bytes", "%s"}, true
+	case "[]string":
+		return binding{"GetStringSliceOk", "[]string", "%s"}, true
+	case "[]int":
+		return binding{"GetIntSliceOk", "[]int", "%s"}, true
+	case "[]uint64":
+		return binding{"GetUint64SliceOk", "[]uint64", "%s"}, true
+	case "[]float64":
+		return binding{"GetFloatSliceOk", "[]float64", "%s"}, true
+	}
+
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok || !g.local(t) {
+		return binding{}, false
+	}
+
+	var b binding
+	switch basic.Kind() {
+	case types.String:
+		g.imports["strings"] = true
+		b = binding{"GetStringOk", "string", `strings.Trim(%s, " ")`}
+	case types.Bool:
+		b = binding{"GetBoolOk", "bool", "%s"}
+	case types.Int:
+		b = binding{"GetIntOk", "int", "%s"}
+	case types.Int8:
+		b = binding{"GetInt8Ok", "int8", "%s"}
+	case types.Int16:
+		b = binding{"GetInt16Ok", "int16", "%s"}
+	case types.Int32:
+		b = binding{"GetInt32Ok", "int32", "%s"}
+	case types.Int64:
+		b = binding{"GetInt64Ok", "int64", "%s"}
+	case types.Uint64:
+		b = binding{"GetUint64Ok", "uint64", "%s"}
+	case types.Float32:
+		b = binding{"GetFloatOk", "float32", "float32(%s)"}
+	case types.Float64:
+		b = binding{"GetFloatOk", "float64", "%s"}
+	default:
+		return binding{}, false
+	}
+
+	if named, ok := t.(*types.Named); ok {
+		b.conv = named.Obj().Name() + "(" + b.conv + ")"
+	}
+	return b, true
+}
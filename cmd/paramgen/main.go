@@ -0,0 +1,75 @@
+// Command paramgen generates reflection free BindParams methods for structs.
+//
+// Annotate the file declaring the structs and run go generate:
+//
+//	//go:generate go run github.com/BakedSoftware/go-parameters/cmd/paramgen -type=Query,Filter
+//
+// then bind in the handler:
+//
+//	err := query.BindParams(parameters.GetParams(req))
+//
+// Parameter keys are derived from field names with CamelToSnakeCase, so a
+// field is bound from the same key Imbue would use:
+//
+//	UserID -> user_id
+//
+// A key that is absent leaves the field untouched, or sets it from its default
+// tag; a key that is present but cannot be converted makes BindParams return a
+// *parameters.TypeError.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of struct type names; must be set")
+	output    = flag.String("output", "", "output file name; default srcdir/<type>_params.go")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of paramgen:\n")
+	fmt.Fprintf(os.Stderr, "\tparamgen -type T[,T...] [-output file] [directory]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("paramgen: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if args := flag.Args(); len(args) > 0 {
+		dir = args[0]
+	}
+
+	names := strings.Split(*typeNames, ",")
+	outName := *output
+	if outName == "" {
+		outName = filepath.Join(dir, strings.ToLower(names[0])+"_params.go")
+	}
+
+	g := &generator{warn: func(msg string) { log.Println(msg) }}
+	pkg, err := g.load(dir, filepath.Base(outName))
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := g.generate(pkg, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(outName, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	var warnings []string
+	g := &generator{warn: func(msg string) { warnings = append(warnings, msg) }}
+
+	pkg, err := g.load("testdata/query", "query_params.go")
+	if err != nil {
+		t.Fatal("Could not load package", err)
+	}
+	src, err := g.generate(pkg, []string{"Query", "Filter"})
+	if err != nil {
+		t.Fatal("Could not generate", err)
+	}
+
+	golden, err := ioutil.ReadFile("testdata/query/query_params.go.golden")
+	if err != nil {
+		t.Fatal("Could not read golden file", err)
+	}
+	if !bytes.Equal(src, golden) {
+		t.Errorf("Generated source differs from golden file, got:\n%s", src)
+	}
+
+	// IDs and URL do not survive the snake_case round trip and time.Duration
+	// is not supported
+	if len(warnings) != 3 {
+		t.Errorf("Expected 3 warnings, got: %q", warnings)
+	}
+}
+
+func TestGenerateNotStruct(t *testing.T) {
+	g := &generator{warn: func(string) {}}
+
+	pkg, err := g.load("testdata/query", "query_params.go")
+	if err != nil {
+		t.Fatal("Could not load package", err)
+	}
+	if _, err := g.generate(pkg, []string{"Order"}); err == nil {
+		t.Error("Expected an error for a non struct type")
+	}
+	if _, err := g.generate(pkg, []string{"Missing"}); err == nil {
+		t.Error("Expected an error for an unknown type")
+	}
+}
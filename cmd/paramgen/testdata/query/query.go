@@ -0,0 +1,32 @@
+package query
+
+import "time"
+
+type Order string
+
+type Page struct {
+	Limit  int `default:"25"`
+	Offset int
+}
+
+type Query struct {
+	Page
+	Search  string
+	UserID  uint64
+	Ratio   float32
+	Order   Order
+	Tags    []string
+	IDs     []uint64
+	Since   time.Time
+	Until   *time.Time
+	Filter  Filter `default:"{\"level\": 1}"`
+	Range   struct{ Min, Max float64 }
+	URL     string
+	Timeout time.Duration
+	hidden  int
+}
+
+type Filter struct {
+	Active bool `default:"TRUE"`
+	Level  int8
+}
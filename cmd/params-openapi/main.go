@@ -0,0 +1,127 @@
+// Command params-openapi prints the OpenAPI 3.1 spec of the routes registered
+// with parameters.RegisterRoute.
+//
+// Register the routes in the init of a package of the application:
+//
+//	func init() {
+//		parameters.RegisterRoute("GET", "/users/{id}", UserQuery{})
+//		parameters.RegisterRoute("POST", "/users", NewUser{})
+//	}
+//
+// then run the command from the module of the application:
+//
+//	go run github.com/BakedSoftware/go-parameters/cmd/params-openapi -title API ./routes
+//
+// The command builds a temporary program importing the packages, so their
+// init functions run, and prints the spec assembled by parameters.OpenAPISpec.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+var (
+	title   = flag.String("title", "API", "title of the spec")
+	version = flag.String("version", "0.0.0", "version of the spec")
+	output  = flag.String("output", "", "output file name; default stdout")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of params-openapi:\n")
+	fmt.Fprintf(os.Stderr, "\tparams-openapi [-title T] [-version V] [-output file] package...\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("params-openapi: ")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	imports, err := resolve(flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var src bytes.Buffer
+	if err := program.Execute(&src, struct {
+		Imports        []string
+		Title, Version string
+	}{imports, *title, *version}); err != nil {
+		log.Fatal(err)
+	}
+
+	// The program lives in the current module so it builds with its requirements
+	dir, err := ioutil.TempDir(".", ".params-openapi")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), src.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			log.Fatal(err)
+		}
+		defer out.Close()
+	}
+
+	cmd := exec.Command("go", "run", "./"+filepath.ToSlash(dir))
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.RemoveAll(dir)
+		log.Fatal(err)
+	}
+}
+
+// resolve returns the import paths of the package patterns
+func resolve(patterns []string) ([]string, error) {
+	args := append([]string{"list", "-f", "{{.ImportPath}}"}, patterns...)
+	cmd := exec.Command("go", args...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
+
+var program = template.Must(template.New("main").Parse(`// Code generated by params-openapi; DO NOT EDIT.
+
+package main
+
+import (
+	"log"
+	"os"
+
+	parameters "github.com/BakedSoftware/go-parameters"
+{{range .Imports}}
+	_ {{printf "%q" .}}
+{{- end}}
+)
+
+func main() {
+	spec, err := parameters.OpenAPISpec({{printf "%q" .Title}}, {{printf "%q" .Version}})
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Stdout.Write(append(spec, '\n'))
+}
+`))
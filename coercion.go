@@ -0,0 +1,99 @@
+package parameters
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// CoercionsKey is the context key of the path param coercions of a route
+	CoercionsKey = "coercions"
+)
+
+// Coercion converts the path params of a given type, Type naming it in the
+// TypeError returned when a value cannot be converted
+type Coercion struct {
+	Type    string
+	Convert func(value string) (interface{}, error)
+}
+
+// CoerceFunc returns a custom coercion
+func CoerceFunc(typeName string, convert func(value string) (interface{}, error)) Coercion {
+	return Coercion{Type: typeName, Convert: convert}
+}
+
+// Coercions of the common path param types
+var (
+	CoerceUint64 = CoerceFunc("uint64", func(value string) (interface{}, error) {
+		return strconv.ParseUint(value, 10, 64)
+	})
+	CoerceInt64 = CoerceFunc("int64", func(value string) (interface{}, error) {
+		return strconv.ParseInt(value, 10, 64)
+	})
+	// CoerceUUID checks the canonical form of a UUID and lower cases it
+	CoerceUUID = CoerceFunc("uuid", func(value string) (interface{}, error) {
+		if !uuidRe.MatchString(value) {
+			return nil, strconv.ErrSyntax
+		}
+		return strings.ToLower(value), nil
+	})
+	CoerceString = CoerceFunc("string", func(value string) (interface{}, error) {
+		return value, nil
+	})
+)
+
+// Coercions maps path param names to their coercion. Path params without a
+// coercion are strings, unless the parser uses the IDHeuristic. Parse returns
+// a TypeError for a path param which cannot be converted, which the parsed
+// request wrappers answer with a 422 problem.
+type Coercions map[string]Coercion
+
+// WithCoercions returns a shallow copy of req with the coercions of its route,
+// which take precedence over those of the parser
+func WithCoercions(req *http.Request, rules Coercions) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), CoercionsKey, rules))
+}
+
+// Handler sets the coercions of the route of h, it must wrap the parsing:
+//
+//	mux.Handle("GET /users/{id}", parameters.Coercions{"id": parameters.CoerceUint64}.Handler(parameters.ParsedHandler(h)))
+func (c Coercions) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, WithCoercions(r, c))
+	})
+}
+
+// Handle sets the coercions of the route of fn, it must wrap the parsing
+func (c Coercions) Handle(fn httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		fn(w, WithCoercions(r, c), p)
+	}
+}
+
+// coerce converts a path param with the coercion of its route or of the
+// parser, or with the id heuristic when enabled
+func (ps *Parser) coerce(req *http.Request, key, value string) (interface{}, error) {
+	routeRules, _ := req.Context().Value(CoercionsKey).(Coercions)
+	rule, ok := routeRules[key]
+	if !ok {
+		rule, ok = ps.Coercions[key]
+	}
+	if ok {
+		v, err := rule.Convert(value)
+		if err != nil {
+			return value, &TypeError{Key: key, Type: rule.Type}
+		}
+		return v, nil
+	}
+
+	if ps.IDHeuristic && strings.Contains(key, "id") {
+		if id, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return id, nil
+		}
+	}
+	return value, nil
+}
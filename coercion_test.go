@@ -0,0 +1,136 @@
+package parameters
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// parsePath parses a GET request to path served by a ServeMux route
+func parsePath(t *testing.T, parser *Parser, pattern, path string) (*Params, error) {
+	var params *Params
+	var err error
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		params, err = parser.Parse(r)
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), buildRequest(t, "GET", path, nil, nil))
+	if params == nil {
+		t.Fatal("ServeMux did not match", path)
+	}
+	return params, err
+}
+
+func TestPathParamsAreStringsByDefault(t *testing.T) {
+	params, err := parsePath(t, &Parser{}, "GET /{guid}/{provider_id}/{idempotency}", "/42/abc/7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"guid": "42", "provider_id": "abc", "idempotency": "7"} {
+		if params.Values[key] != want {
+			t.Errorf("Expected %s to be %q, got: %#v", key, want, params.Values[key])
+		}
+	}
+}
+
+func TestParserCoercions(t *testing.T) {
+	parser := &Parser{Coercions: Coercions{
+		"user_id": CoerceUint64,
+		"offset":  CoerceInt64,
+		"token":   CoerceUUID,
+		"slug": CoerceFunc("slug", func(value string) (interface{}, error) {
+			return "slug:" + value, nil
+		}),
+	}}
+
+	params, err := parsePath(t, parser, "GET /{user_id}/{offset}/{token}/{slug}", "/42/-3/0F8FAD5B-D9CB-469F-A165-70867728950E/hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"user_id": uint64(42),
+		"offset":  int64(-3),
+		"token":   "0f8fad5b-d9cb-469f-a165-70867728950e",
+		"slug":    "slug:hello",
+	}
+	for key, want := range expected {
+		if params.Values[key] != want {
+			t.Errorf("Expected %s to be %#v, got: %#v", key, want, params.Values[key])
+		}
+	}
+
+	_, err = parsePath(t, parser, "GET /{user_id}/{offset}/{token}/{slug}", "/abc/1/0F8FAD5B-D9CB-469F-A165-70867728950E/x")
+	var typeErr *TypeError
+	if !errors.As(err, &typeErr) || typeErr.Key != "user_id" || typeErr.Type != "uint64" {
+		t.Error("Expected a TypeError for user_id, got:", err)
+	}
+}
+
+func TestRouteCoercionsTakePrecedence(t *testing.T) {
+	parser := &Parser{Coercions: Coercions{"id": CoerceUUID}, IDHeuristic: true}
+	var params *Params
+	mux := http.NewServeMux()
+	mux.Handle("GET /videos/{id}/{video_id}", Coercions{"id": CoerceString}.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ = parser.Parse(r)
+	})))
+	mux.ServeHTTP(httptest.NewRecorder(), buildRequest(t, "GET", "/videos/12/34", nil, nil))
+
+	if params.Values["id"] != "12" || params.Values["video_id"] != uint64(34) {
+		t.Error("Unexpected params:", params.Values)
+	}
+}
+
+func TestMakeValidatedReqCoercionError(t *testing.T) {
+	defer func(rules Coercions) { DefaultParser.Coercions = rules }(DefaultParser.Coercions)
+	DefaultParser.Coercions = Coercions{"id": CoerceUint64}
+
+	type query struct{ Id uint64 }
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", MakeValidatedReq(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("The handler should not be called")
+	}, query{}))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, buildRequest(t, "GET", "/users/abc", nil, nil))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatal("Expected status 422, got:", w.Code)
+	}
+	var problem Problem
+	json.NewDecoder(w.Body).Decode(&problem)
+	if len(problem.Errors) != 1 || problem.Errors[0].Field != "id" || problem.Errors[0].Code != "type" {
+		t.Errorf("Unexpected problem: %+v", problem)
+	}
+}
+
+func TestParsedReqCoercionError(t *testing.T) {
+	handlers := map[string]http.Handler{
+		"ParsedHandler": ParsedHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("The handler should not be called")
+		})),
+		"MakeHTTPRouterParsedReq": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			MakeHTTPRouterParsedReq(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+				t.Error("The handler should not be called")
+			})(w, r, httprouter.Params{{Key: "id", Value: r.PathValue("id")}})
+		}),
+	}
+	for name, h := range handlers {
+		mux := http.NewServeMux()
+		mux.Handle("GET /users/{id}", Coercions{"id": CoerceUint64}.Handler(h))
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, buildRequest(t, "GET", "/users/abc", nil, nil))
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Error(name, "expected status 422, got:", w.Code)
+			continue
+		}
+		var problem Problem
+		json.NewDecoder(w.Body).Decode(&problem)
+		if len(problem.Errors) != 1 || problem.Errors[0].Field != "id" || problem.Errors[0].Code != "type" {
+			t.Errorf("%s: unexpected problem: %+v", name, problem)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package parameters
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Compressor compresses the responses with the encoding preferred by the
+// client among gzip and deflate. Responses to HEAD requests, without content
+// (1xx, 204, 304), already encoded, smaller than MinSize or whose content
+// type is not allowed are sent as is.
+type Compressor struct {
+	// MinSize is the size under which a response is not compressed, it is
+	// buffered until then
+	MinSize int
+
+	// ContentTypes lists the media types compressed, an entry ending with a
+	// slash matching the whole type: text/
+	ContentTypes []string
+
+	// Encodings lists the encodings used in order of preference, gzip and
+	// deflate when empty
+	Encodings []string
+
+	// Level is the compression level, gzip.DefaultCompression when 0
+	Level int
+
+	pools [2]sync.Pool
+}
+
+// compressor is implemented by gzip.Writer and zlib.Writer
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encodings holds the supported encodings, by index in Compressor.pools
+var encodings = []string{"gzip", "deflate"}
+
+// DefaultCompressor is used by EnableCompression and EnableGZIP
+var DefaultCompressor = &Compressor{
+	MinSize: 1024,
+	ContentTypes: []string{
+		"text/",
+		"application/json",
+		"application/problem+json",
+		"application/javascript",
+		"application/xml",
+		"application/x-msgpack",
+		"image/svg+xml",
+	},
+}
+
+// encodingQuality returns the q-value given to coding by an Accept-Encoding
+// header, explicitly or through *
+func encodingQuality(acceptEncoding, coding string) float64 {
+	wildcard := 0.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		switch name {
+		case coding:
+			return q
+		case "*":
+			wildcard = q
+		}
+	}
+	return wildcard
+}
+
+// acceptsEncoding reports if an Accept-Encoding header accepts coding with a
+// q-value above zero
+func acceptsEncoding(acceptEncoding, coding string) bool {
+	return encodingQuality(acceptEncoding, coding) > 0
+}
+
+// negotiateEncoding returns the encoding of offered with the highest q-value,
+// the first one on ties, or an empty string when none is accepted
+func negotiateEncoding(acceptEncoding string, offered []string) string {
+	best, bestQ := "", 0.0
+	for _, coding := range offered {
+		if q := encodingQuality(acceptEncoding, coding); q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+func (c *Compressor) allowsType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range c.ContentTypes {
+		if strings.HasSuffix(allowed, "/") && strings.HasPrefix(mediaType, allowed) || mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Compressor) writer(encoding string) compressor {
+	i := indexOf(encodings, encoding)
+	if cw, ok := c.pools[i].Get().(compressor); ok {
+		return cw
+	}
+	level := c.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	if encoding == "deflate" {
+		if zw, err := zlib.NewWriterLevel(nil, level); err == nil {
+			return zw
+		}
+		return zlib.NewWriter(nil)
+	}
+	if gz, err := gzip.NewWriterLevel(nil, level); err == nil {
+		return gz
+	}
+	return gzip.NewWriter(nil)
+}
+
+func (c *Compressor) release(encoding string, cw compressor) {
+	cw.Reset(nil)
+	c.pools[indexOf(encodings, encoding)].Put(cw)
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// serve calls fn with a compressing writer when the client accepts one of
+// offered
+func (c *Compressor) serve(w http.ResponseWriter, r *http.Request, offered []string, fn func(http.ResponseWriter, *http.Request)) {
+	w.Header().Add("Vary", "Accept-Encoding")
+	encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), offered)
+	if r.Method == http.MethodHead || encoding == "" {
+		fn(w, r)
+		return
+	}
+	cw := &compressWriter{ResponseWriter: w, c: c, encoding: encoding}
+	defer cw.Close()
+	fn(cw, r)
+}
+
+func (c *Compressor) offered() []string {
+	if len(c.Encodings) == 0 {
+		return encodings
+	}
+	offered := make([]string, 0, len(c.Encodings))
+	for _, encoding := range c.Encodings {
+		if encoding = strings.ToLower(encoding); indexOf(encodings, encoding) >= 0 {
+			offered = append(offered, encoding)
+		}
+	}
+	return offered
+}
+
+// Handler compresses the responses of h
+func (c *Compressor) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.serve(w, r, c.offered(), h.ServeHTTP)
+	})
+}
+
+// Handle compresses the responses of fn
+func (c *Compressor) Handle(fn httprouter.Handle) httprouter.Handle {
+	return c.handle(fn, nil)
+}
+
+// handle compresses the responses of fn with the encodings offered, those of
+// the compressor when nil
+func (c *Compressor) handle(fn httprouter.Handle, offered []string) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		offered := offered
+		if offered == nil {
+			offered = c.offered()
+		}
+		c.serve(w, r, offered, func(w http.ResponseWriter, r *http.Request) { fn(w, r, p) })
+	}
+}
+
+// EnableCompression compresses the response with gzip or deflate, as
+// negotiated with the Accept-Encoding header, see DefaultCompressor
+func EnableCompression(fn httprouter.Handle) httprouter.Handle {
+	return DefaultCompressor.Handle(fn)
+}
+
+// EnableCompressionHandler is the http.Handler flavor of EnableCompression
+func EnableCompressionHandler(h http.Handler) http.Handler {
+	return DefaultCompressor.Handler(h)
+}
+
+// compressWriter buffers the start of a response until it can decide to
+// compress it
+type compressWriter struct {
+	http.ResponseWriter
+	c *Compressor
+
+	encoding string
+	status   int
+	buf      []byte
+	decided  bool
+	cw       compressor
+	hijacked bool
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if status < http.StatusOK {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.c.MinSize {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.cw != nil {
+		return w.cw.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// decide sends the headers and the buffered body, compressed if allowed
+func (w *compressWriter) decide(allowed bool) error {
+	w.decided = true
+	h := w.Header()
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		// If no content type, apply sniffing algorithm to uncompressed body.
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	if allowed && h.Get("Content-Encoding") == "" && w.c.allowsType(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		w.cw = w.c.writer(w.encoding)
+		w.cw.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.cw != nil {
+		_, err = w.cw.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// Flush sends the buffered body, compressing it regardless of its size as
+// more is expected to follow
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.cw != nil {
+		w.cw.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack takes over the connection, the response is not written anymore
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("parameters: the response writer does not implement http.Hijacker")
+	}
+	conn, rw, err := hj.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Close sends the rest of the response
+func (w *compressWriter) Close() error {
+	if w.hijacked {
+		return nil
+	}
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			return nil
+		}
+		w.decide(false)
+	}
+	if w.cw == nil {
+		return nil
+	}
+	err := w.cw.Close()
+	w.c.release(w.encoding, w.cw)
+	w.cw = nil
+	return err
+}
@@ -0,0 +1,177 @@
+package parameters
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestAcceptsEncoding(t *testing.T) {
+	cases := map[string]bool{
+		"gzip":                 true,
+		"deflate, gzip;q=0.5":  true,
+		"gzip;q=0":             false,
+		"gzip;q=0.0, deflate":  false,
+		"*":                    true,
+		"*;q=0":                false,
+		"br, *;q=0.1":          true,
+		"gzip;q=0, *":          false,
+		"identity":             false,
+		"":                     false,
+		"x-gzip-not-really, a": false,
+	}
+	for header, want := range cases {
+		if got := acceptsEncoding(header, "gzip"); got != want {
+			t.Errorf("acceptsEncoding(%q) = %v, expected %v", header, got, want)
+		}
+	}
+}
+
+func TestCompressorCompresses(t *testing.T) {
+	body := strings.Repeat("hello world ", 200)
+	h := DefaultCompressor.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Length", "2400")
+		io.WriteString(w, body[:100])
+		io.WriteString(w, body[100:])
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, buildRequest(t, "GET", "/", nil, http.Header{"Accept-Encoding": {"gzip"}}))
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Content-Length") != "" {
+		t.Fatal("Unexpected headers:", w.Header())
+	}
+	if w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Error("Expected Vary: Accept-Encoding, got:", w.Header().Get("Vary"))
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(gz); string(data) != body {
+		t.Error("Unexpected body:", string(data))
+	}
+}
+
+func TestCompressorSkips(t *testing.T) {
+	large := strings.Repeat("a", 2048)
+	cases := []struct {
+		name    string
+		r       *http.Request
+		handler http.HandlerFunc
+	}{
+		{"q=0", buildRequest(t, "GET", "/", nil, http.Header{"Accept-Encoding": {"gzip;q=0"}}), func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, large)
+		}},
+		{"small", buildRequest(t, "GET", "/", nil, http.Header{"Accept-Encoding": {"gzip"}}), func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "small")
+		}},
+		{"HEAD", buildRequest(t, "HEAD", "/", nil, http.Header{"Accept-Encoding": {"gzip"}}), func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", "2048")
+		}},
+		{"204", buildRequest(t, "GET", "/", nil, http.Header{"Accept-Encoding": {"gzip"}}), func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}},
+		{"304", buildRequest(t, "GET", "/", nil, http.Header{"Accept-Encoding": {"gzip"}}), func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotModified)
+		}},
+		{"image", buildRequest(t, "GET", "/", nil, http.Header{"Accept-Encoding": {"gzip"}}), func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			io.WriteString(w, large)
+		}},
+		{"encoded", buildRequest(t, "GET", "/", nil, http.Header{"Accept-Encoding": {"gzip"}}), func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Encoding", "br")
+			io.WriteString(w, large)
+		}},
+	}
+
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		DefaultCompressor.Handler(tc.handler).ServeHTTP(w, tc.r)
+		if w.Header().Get("Content-Encoding") == "gzip" {
+			t.Errorf("%s: expected the response not to be compressed", tc.name)
+		}
+		if tc.name == "small" && w.Body.String() != "small" {
+			t.Errorf("%s: unexpected body %q", tc.name, w.Body.String())
+		}
+		if tc.name == "204" && w.Code != http.StatusNoContent {
+			t.Errorf("%s: unexpected status %d", tc.name, w.Code)
+		}
+	}
+}
+
+func TestCompressorFlush(t *testing.T) {
+	h := DefaultCompressor.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: 1\n\n")
+		w.(http.Flusher).Flush()
+		if _, ok := w.(http.Hijacker); !ok {
+			t.Error("Expected the writer to implement http.Hijacker")
+		}
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, buildRequest(t, "GET", "/", nil, http.Header{"Accept-Encoding": {"gzip"}}))
+	if !w.Flushed || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("Expected a flushed gzip response:", w.Header())
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(gz); string(data) != "data: 1\n\n" {
+		t.Error("Unexpected body:", string(data))
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	cases := map[string]string{
+		"gzip, deflate":              "gzip",
+		"deflate":                    "deflate",
+		"gzip;q=0.5, deflate":        "deflate",
+		"gzip;q=0, deflate;q=0":      "",
+		"*":                          "gzip",
+		"br, deflate;q=0.8, *;q=0.1": "deflate",
+	}
+	for header, want := range cases {
+		if got := negotiateEncoding(header, encodings); got != want {
+			t.Errorf("negotiateEncoding(%q) = %q, expected %q", header, got, want)
+		}
+	}
+}
+
+func TestEnableCompressionDeflate(t *testing.T) {
+	body := strings.Repeat(`{"hello": "world"}`, 100)
+	handle := EnableCompression(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	})
+
+	w := httptest.NewRecorder()
+	handle(w, buildRequest(t, "GET", "/", nil, http.Header{"Accept-Encoding": {"deflate, gzip;q=0.5"}}), nil)
+	if w.Header().Get("Content-Encoding") != "deflate" {
+		t.Fatal("Unexpected headers:", w.Header())
+	}
+	zr, err := zlib.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(zr); string(data) != body {
+		t.Error("Unexpected body:", string(data))
+	}
+
+	w = httptest.NewRecorder()
+	EnableGZIP(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		io.WriteString(w, body)
+	})(w, buildRequest(t, "GET", "/", nil, http.Header{"Accept-Encoding": {"deflate"}}), nil)
+	if w.Header().Get("Content-Encoding") != "" {
+		t.Error("EnableGZIP should only use gzip, got:", w.Header().Get("Content-Encoding"))
+	}
+}
@@ -0,0 +1,213 @@
+package parameters
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// CORSPolicy describes the cross origin requests allowed by a server. Requests
+// from other origins are answered with 403 Forbidden, preflight requests are
+// answered with 204 No Content without calling the handler:
+//
+//	cors := &parameters.CORSPolicy{
+//		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+//		AllowCredentials: true,
+//		MaxAge:           time.Hour,
+//	}
+//	router.GET("/users", cors.Handle(handle))
+//	router.GlobalOPTIONS = cors.Preflight()
+type CORSPolicy struct {
+	// AllowedOrigins lists the allowed origins, which may contain * wildcards
+	// matching a part of the host name, "*" alone allowing any origin
+	AllowedOrigins []string
+
+	// AllowOriginFunc allows the origins it returns true for, in addition to
+	// AllowedOrigins
+	AllowOriginFunc func(origin string) bool
+
+	// AllowedMethods defaults to GET, HEAD and POST
+	AllowedMethods []string
+
+	// AllowedHeaders lists the request headers allowed, "*" allowing any
+	AllowedHeaders []string
+
+	// ExposedHeaders lists the response headers readable by the client
+	ExposedHeaders []string
+
+	// MaxAge is how long a preflight response may be cached
+	MaxAge time.Duration
+
+	// AllowCredentials allows cookies and authorization headers. It is
+	// ignored when AllowedOrigins contains "*": any origin is then answered
+	// with a literal "*", which browsers never send credentials to.
+	AllowCredentials bool
+
+	// AllowPrivateNetwork allows public sites to reach a server on a private
+	// network, answering the Private Network Access preflight
+	AllowPrivateNetwork bool
+}
+
+var defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+
+// AllowsOrigin reports if the policy allows requests from origin
+func (c *CORSPolicy) AllowsOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	lower := strings.ToLower(origin)
+	for _, allowed := range c.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == lower {
+			return true
+		}
+		if strings.Contains(allowed, "*") {
+			if ok, _ := path.Match(allowed, lower); ok {
+				return true
+			}
+		}
+	}
+	return c.AllowOriginFunc != nil && c.AllowOriginFunc(origin)
+}
+
+func (c *CORSPolicy) allowsMethod(method string) bool {
+	methods := c.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CORSPolicy) allowsHeader(header string) bool {
+	for _, h := range c.AllowedHeaders {
+		if h == "*" || strings.EqualFold(h, header) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CORSPolicy) anyOrigin() bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// setOrigin sets the origin and credentials headers of an allowed request, a
+// policy allowing any origin never echoes it nor allows credentials
+func (c *CORSPolicy) setOrigin(h http.Header, origin string) {
+	if c.anyOrigin() {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if c.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// serve applies the policy to a request, it returns false when the request
+// was answered and must not reach the handler
+func (c *CORSPolicy) serve(w http.ResponseWriter, r *http.Request) bool {
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	if preflight {
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+	}
+	if !c.AllowsOrigin(origin) {
+		w.WriteHeader(http.StatusForbidden)
+		return false
+	}
+	if !preflight {
+		c.setOrigin(h, origin)
+		if len(c.ExposedHeaders) > 0 {
+			h.Set("Access-Control-Expose-Headers", strings.Join(c.ExposedHeaders, ", "))
+		}
+		return true
+	}
+
+	method := r.Header.Get("Access-Control-Request-Method")
+	if !c.allowsMethod(method) {
+		w.WriteHeader(http.StatusForbidden)
+		return false
+	}
+	var headers []string
+	for _, list := range r.Header.Values("Access-Control-Request-Headers") {
+		for _, header := range strings.Split(list, ",") {
+			if header = strings.TrimSpace(header); header == "" {
+				continue
+			}
+			if !c.allowsHeader(header) {
+				w.WriteHeader(http.StatusForbidden)
+				return false
+			}
+			headers = append(headers, header)
+		}
+	}
+	if r.Header.Get("Access-Control-Request-Private-Network") == "true" {
+		if !c.AllowPrivateNetwork {
+			w.WriteHeader(http.StatusForbidden)
+			return false
+		}
+		h.Set("Access-Control-Allow-Private-Network", "true")
+	}
+
+	c.setOrigin(h, origin)
+	h.Set("Access-Control-Allow-Methods", strings.ToUpper(method))
+	if len(headers) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	}
+	if c.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge/time.Second)))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return false
+}
+
+// Handler applies the policy to the requests served by h
+func (c *CORSPolicy) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.serve(w, r) {
+			h.ServeHTTP(w, r)
+		}
+	})
+}
+
+// Handle applies the policy to the requests served by fn
+func (c *CORSPolicy) Handle(fn httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if c.serve(w, r) {
+			fn(w, r, p)
+		}
+	}
+}
+
+// Preflight answers the preflight requests, to be set as the GlobalOPTIONS
+// handler of an httprouter.Router. Other OPTIONS requests are answered with
+// 204 No Content.
+func (c *CORSPolicy) Preflight() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.serve(w, r) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package parameters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestCORSPolicyActualRequest(t *testing.T) {
+	policy := &CORSPolicy{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowOriginFunc:  func(origin string) bool { return origin == "http://localhost:3000" },
+		ExposedHeaders:   []string{"X-Total"},
+		AllowCredentials: true,
+	}
+	called := false
+	h := policy.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+
+	for _, origin := range []string{"https://app.example.com", "https://api.example.org", "http://localhost:3000"} {
+		called = false
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, buildRequest(t, "GET", "/users", nil, http.Header{"Origin": {origin}}))
+		if !called || w.Header().Get("Access-Control-Allow-Origin") != origin {
+			t.Errorf("Expected %s to be allowed: %v", origin, w.Header())
+		}
+		if w.Header().Get("Access-Control-Allow-Credentials") != "true" || w.Header().Get("Access-Control-Expose-Headers") != "X-Total" {
+			t.Error("Unexpected headers:", w.Header())
+		}
+		if w.Header().Get("Vary") != "Origin" {
+			t.Error("Expected Vary: Origin, got:", w.Header().Values("Vary"))
+		}
+	}
+
+	called = false
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, buildRequest(t, "GET", "/users", nil, http.Header{"Origin": {"https://evil.com"}}))
+	if called || w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("Expected the origin to be rejected, got:", w.Code, w.Header())
+	}
+
+	called = false
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, buildRequest(t, "GET", "/users", nil, nil))
+	if !called || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("Expected a same origin request to pass through")
+	}
+}
+
+func TestCORSPolicyPreflight(t *testing.T) {
+	policy := &CORSPolicy{
+		AllowedOrigins:      []string{"*"},
+		AllowedMethods:      []string{"GET", "PUT"},
+		AllowedHeaders:      []string{"Content-Type", "X-CSRF-Token"},
+		MaxAge:              10 * time.Minute,
+		AllowPrivateNetwork: true,
+	}
+	h := policy.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Preflight requests should not reach the handler")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, buildRequest(t, "OPTIONS", "/users", nil, http.Header{
+		"Origin":                                 {"https://app.example.com"},
+		"Access-Control-Request-Method":          {"PUT"},
+		"Access-Control-Request-Headers":         {"content-type, x-csrf-token"},
+		"Access-Control-Request-Private-Network": {"true"},
+	}))
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":          "*",
+		"Access-Control-Allow-Methods":         "PUT",
+		"Access-Control-Allow-Headers":         "content-type, x-csrf-token",
+		"Access-Control-Max-Age":               "600",
+		"Access-Control-Allow-Private-Network": "true",
+	}
+	if w.Code != http.StatusNoContent {
+		t.Error("Expected status 204, got:", w.Code)
+	}
+	for k, v := range expected {
+		if got := w.Header().Get(k); got != v {
+			t.Errorf("Expected %s: %s, got: %s", k, v, got)
+		}
+	}
+
+	for _, headers := range []http.Header{
+		{"Origin": {"https://app.example.com"}, "Access-Control-Request-Method": {"DELETE"}},
+		{"Origin": {"https://app.example.com"}, "Access-Control-Request-Method": {"GET"}, "Access-Control-Request-Headers": {"Authorization"}},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, buildRequest(t, "OPTIONS", "/users", nil, headers))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected %v to be rejected, got: %d", headers, w.Code)
+		}
+	}
+}
+
+func TestCORSPolicyHandle(t *testing.T) {
+	policy := &CORSPolicy{AllowedOrigins: []string{"https://app.example.com"}}
+
+	w := httptest.NewRecorder()
+	policy.Preflight().ServeHTTP(w, buildRequest(t, "OPTIONS", "/users", nil, http.Header{
+		"Origin":                        {"https://app.example.com"},
+		"Access-Control-Request-Method": {"POST"},
+	}))
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Error("Unexpected preflight response:", w.Code, w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	handle := policy.Handle(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {})
+	handle(w, buildRequest(t, "POST", "/users", nil, http.Header{"Origin": {"https://other.example.com"}}), nil)
+	if w.Code != http.StatusForbidden {
+		t.Error("Expected status 403, got:", w.Code)
+	}
+}
+
+func TestCORSPolicyAnyOriginCredentials(t *testing.T) {
+	policy := &CORSPolicy{AllowedOrigins: []string{"*"}, AllowCredentials: true}
+	h := policy.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, method := range []string{"GET", "OPTIONS"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, buildRequest(t, method, "/users", nil, http.Header{
+			"Origin":                        {"https://evil.com"},
+			"Access-Control-Request-Method": {"GET"},
+		}))
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("%s: expected a literal *, got: %s", method, got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("%s: expected no credentials, got: %s", method, got)
+		}
+	}
+}
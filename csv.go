@@ -0,0 +1,199 @@
+package parameters
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// CSVError is returned by the CSV decoders when a line cannot be decoded or
+// when the handler of its row fails
+type CSVError struct {
+	// Line of the row, from 1 for the header line
+	Line int
+	Err  error
+}
+
+func (e *CSVError) Error() string {
+	return fmt.Sprintf("parameters: csv line %d: %v", e.Line, e.Err)
+}
+
+func (e *CSVError) Unwrap() error {
+	return e.Err
+}
+
+// CSV decodes text/csv bodies into one Params per row, keyed by the header
+// line. The header names are normalized with CamelToSnakeCase, "Unit Price"
+// and "UnitPrice" both becoming unit_price, and a UTF-8 BOM is stripped.
+type CSV struct {
+	// Comma is the field delimiter, ',' when 0
+	Comma rune
+
+	// Field is the name of the multipart file part holding the CSV, the
+	// first file part when empty
+	Field string
+
+	// RawHeader keeps the header names as they are
+	RawHeader bool
+
+	// Logger is set to the params of the rows
+	Logger *slog.Logger
+}
+
+// DefaultCSV is the decoder used by ForEachCSVRow
+var DefaultCSV = &CSV{}
+
+// ForEachCSVRow calls fn with the params of each row of a CSV body with
+// DefaultCSV, see CSV.ForEach
+func ForEachCSVRow(req *http.Request, fn func(*Params) error) error {
+	return DefaultCSV.ForEach(req, fn)
+}
+
+// ForEach calls fn with the params of each row of a text/csv body, or of a
+// file part of a multipart/form-data body. It stops on the first line which
+// cannot be decoded or whose handler fails, and returns a CSVError with its
+// line number.
+func (c *CSV) ForEach(req *http.Request, fn func(*Params) error) error {
+	ct, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch ct {
+	case "text/csv":
+		var r io.Reader = req.Body
+		encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+		if encoding != "" && encoding != "identity" {
+			var err error
+			if r, err = decompressReader(encoding, req.Body); err != nil {
+				return err
+			}
+		}
+		return c.ForEachReader(r, fn)
+	case "multipart/form-data":
+		return c.forEachPart(req, fn)
+	}
+	return fmt.Errorf("parameters: unsupported CSV Content-Type %q", ct)
+}
+
+// forEachPart decodes the CSV file part of a multipart body, streamed unless
+// the form was already parsed
+func (c *CSV) forEachPart(req *http.Request, fn func(*Params) error) error {
+	if req.MultipartForm != nil {
+		fields := make([]string, 0, len(req.MultipartForm.File))
+		for field := range req.MultipartForm.File {
+			if c.Field == "" || field == c.Field {
+				fields = append(fields, field)
+			}
+		}
+		if len(fields) == 0 {
+			return errors.New("parameters: no CSV file part")
+		}
+		sort.Strings(fields)
+		f, err := req.MultipartForm.File[fields[0]][0].Open()
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return c.ForEachReader(f, fn)
+	}
+
+	mr, err := req.MultipartReader()
+	if err != nil {
+		return err
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return errors.New("parameters: no CSV file part")
+		}
+		if err != nil {
+			return err
+		}
+		if part.FileName() != "" && (c.Field == "" || part.FormName() == c.Field) {
+			defer part.Close()
+			return c.ForEachReader(part, fn)
+		}
+		part.Close()
+	}
+}
+
+// ForEachReader calls fn with the params of each row of CSV data read from r,
+// see ForEach
+func (c *CSV) ForEachReader(r io.Reader, fn func(*Params) error) error {
+	br := bufio.NewReader(r)
+	if bom, _ := br.Peek(3); bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+		br.Discard(3)
+	}
+
+	cr := csv.NewReader(br)
+	if c.Comma != 0 {
+		cr.Comma = c.Comma
+	}
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return csvError(1, err)
+	}
+	keys := make([]string, len(header))
+	for i, name := range header {
+		keys[i] = name
+		if !c.RawHeader {
+			keys[i] = normalizeHeader(name)
+		}
+	}
+
+	line := 1
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return csvError(line+1, err)
+		}
+		line, _ = cr.FieldPos(0)
+
+		values := make(map[string]interface{}, len(keys))
+		for i, key := range keys {
+			values[key] = formValue(record[i])
+		}
+		row := &Params{logger: c.Logger}
+		row.setSource(SourceCSV, values)
+		row.merge(false)
+		if err := fn(row); err != nil {
+			return &CSVError{Line: line, Err: err}
+		}
+	}
+}
+
+// csvError reports the line of a csv.ParseError, or the line following the
+// last record for other errors
+func csvError(line int, err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &CSVError{Line: parseErr.Line, Err: parseErr.Err}
+	}
+	return &CSVError{Line: line, Err: err}
+}
+
+// normalizeHeader converts a header name to snake case, word by word
+func normalizeHeader(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	normalized := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = CamelToSnakeCase(word); word != "" {
+			normalized = append(normalized, word)
+		}
+	}
+	return strings.Join(normalized, "_")
+}
@@ -0,0 +1,115 @@
+package parameters
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestForEachCSVRow(t *testing.T) {
+	header := http.Header{"Content-Type": {"text/csv; charset=utf-8"}}
+	type product struct {
+		Sku       string
+		UnitPrice float64
+		InStock   bool
+	}
+	body := "\xef\xbb\xbfSKU,Unit Price,inStock\nA-1,9.5,true\nB-2,12,false\n"
+
+	var products []product
+	err := ForEachCSVRow(buildRequest(t, "POST", "/imports", strings.NewReader(body), header), func(p *Params) error {
+		var obj product
+		p.Imbue(&obj)
+		products = append(products, obj)
+		if p.Source("sku") != SourceCSV {
+			t.Error("Unexpected source:", p.Source("sku"))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []product{{"A-1", 9.5, true}, {"B-2", 12, false}}
+	if len(products) != 2 || products[0] != expected[0] || products[1] != expected[1] {
+		t.Errorf("Unexpected products: %+v", products)
+	}
+
+	decoder := &CSV{Comma: ';', RawHeader: true}
+	var keys []string
+	decoder.ForEach(buildRequest(t, "POST", "/imports", strings.NewReader("Unit Price;user_id\n1;2\n"), header), func(p *Params) error {
+		for k := range p.Values {
+			keys = append(keys, k)
+		}
+		return nil
+	})
+	if len(keys) != 2 || (keys[0] != "Unit Price" && keys[1] != "Unit Price") {
+		t.Error("Expected the raw headers, got:", keys)
+	}
+}
+
+func TestForEachCSVRowErrors(t *testing.T) {
+	header := http.Header{"Content-Type": {"text/csv; charset=utf-8"}}
+	noop := func(*Params) error { return nil }
+	var csvErr *CSVError
+
+	err := ForEachCSVRow(buildRequest(t, "POST", "/imports", strings.NewReader("a,b\n1,2\n3\n"), header), noop)
+	if !errors.As(err, &csvErr) || csvErr.Line != 3 {
+		t.Error("Expected an error on line 3, got:", err)
+	}
+
+	err = ForEachCSVRow(buildRequest(t, "POST", "/imports", strings.NewReader("a,b\n1,2\n\"3,4\n"), header), noop)
+	if !errors.As(err, &csvErr) || csvErr.Line != 3 {
+		t.Error("Expected an error on line 3, got:", err)
+	}
+
+	stop := errors.New("stop")
+	err = ForEachCSVRow(buildRequest(t, "POST", "/imports", strings.NewReader("a\n1\n\"multi\nline\"\n2\n"), header), func(p *Params) error {
+		if p.GetString("a") == "2" {
+			return stop
+		}
+		return nil
+	})
+	if !errors.As(err, &csvErr) || csvErr.Line != 5 || !errors.Is(err, stop) {
+		t.Error("Expected the handler error on line 5, got:", err)
+	}
+}
+
+func TestForEachCSVRowMultipart(t *testing.T) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	w.WriteField("note", "weekly")
+	part, _ := w.CreateFormFile("upload", "products.csv")
+	part.Write([]byte("sku\nA-1\nB-2\n"))
+	w.Close()
+	newRequest := func() *http.Request {
+		return buildRequest(t, "POST", "/imports", bytes.NewReader(buf.Bytes()), http.Header{"Content-Type": {w.FormDataContentType()}})
+	}
+
+	var streamed, parsed []string
+	if err := ForEachCSVRow(newRequest(), func(p *Params) error {
+		streamed = append(streamed, p.GetString("sku"))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newRequest()
+	ParseParams(r)
+	decoder := &CSV{Field: "upload"}
+	if err := decoder.ForEach(r, func(p *Params) error {
+		parsed = append(parsed, p.GetString("sku"))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(streamed, ",") != "A-1,B-2" || strings.Join(parsed, ",") != "A-1,B-2" {
+		t.Error("Unexpected rows:", streamed, parsed)
+	}
+
+	decoder = &CSV{Field: "missing"}
+	if err := decoder.ForEach(newRequest(), func(*Params) error { return nil }); err == nil {
+		t.Error("Expected an error for a missing part")
+	}
+}
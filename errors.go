@@ -0,0 +1,14 @@
+package parameters
+
+import "fmt"
+
+// TypeError is returned when a parameter is present but its value cannot be
+// converted to the requested type
+type TypeError struct {
+	Key  string
+	Type string
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("parameter %q is not a valid %s", e.Key, e.Type)
+}
@@ -1,6 +1,6 @@
 module github.com/BakedSoftware/go-parameters
 
-go 1.14
+go 1.23.0
 
 require (
 	github.com/gorilla/mux v1.8.0
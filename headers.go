@@ -0,0 +1,85 @@
+package parameters
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// headerValues returns the headers mapped by the parser, under their key
+func (ps *Parser) headerValues(req *http.Request) map[string]interface{} {
+	values := make(map[string]interface{}, len(ps.Headers))
+	for name, key := range ps.Headers {
+		if v := req.Header.Values(name); len(v) > 0 {
+			values[key] = formValue(v[0])
+		}
+	}
+	return values
+}
+
+// cookieValues returns the cookies mapped by the parser, under their key
+func (ps *Parser) cookieValues(req *http.Request) map[string]interface{} {
+	values := make(map[string]interface{}, len(ps.Cookies))
+	for name, key := range ps.Cookies {
+		if cookie, err := req.Cookie(name); err == nil {
+			values[key] = formValue(cookie.Value)
+		}
+	}
+	return values
+}
+
+// boundTag returns the header or cookie tag binding a field, if any
+func boundTag(field reflect.StructField) (tag, name string, ok bool) {
+	for _, tag := range [...]string{"header", "cookie"} {
+		if name, ok := field.Tag.Lookup(tag); ok && name != "" {
+			return tag, name, true
+		}
+	}
+	return "", "", false
+}
+
+// boundValue returns the value of the header or cookie of the request the
+// params were parsed from
+func (p *Params) boundValue(tag, name string) (string, bool) {
+	if tag == "header" {
+		if v := p.header.Values(name); len(v) > 0 {
+			return v[0], true
+		}
+		return "", false
+	}
+	for _, cookie := range p.cookies {
+		if cookie.Name == name {
+			return cookie.Value, true
+		}
+	}
+	return "", false
+}
+
+// imbueBound sets the fields tagged with a header or cookie from the request
+// the params were parsed from, it appends the headers and cookies which could
+// not be converted to invalid, under their name
+func (p *Params) imbueBound(typeOfObject reflect.Type, objectValue reflect.Value, found map[string]bool, invalid *[]invalidParam) {
+	for i := 0; i < typeOfObject.NumField(); i++ {
+		fieldType := typeOfObject.Field(i)
+		if fieldType.Anonymous && fieldType.Type.Kind() == reflect.Struct {
+			p.imbueBound(fieldType.Type, objectValue.Field(i), found, invalid)
+			continue
+		}
+
+		tag, name, ok := boundTag(fieldType)
+		if !ok || fieldType.PkgPath != "" {
+			continue
+		}
+		v, ok := p.boundValue(tag, name)
+		if !ok {
+			continue
+		}
+
+		// The value is set under a plain key, Get would split a dotted name
+		value := formValue(v)
+		bound := &Params{logger: p.logger, Values: map[string]interface{}{"value": value}}
+		if !bound.setField(objectValue, fieldType.Name, fieldType.Type, "value") {
+			*invalid = append(*invalid, invalidParam{key: name, field: fieldType, value: value})
+		}
+		found[fieldType.Name] = true
+	}
+}
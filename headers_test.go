@@ -0,0 +1,141 @@
+package parameters
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParserHeadersAndCookies(t *testing.T) {
+	parser := &Parser{
+		Headers: map[string]string{"X-Api-Version": "api_version", "X-Device-Id": "device_id"},
+		Cookies: map[string]string{"session": "session_id"},
+	}
+	r := httptest.NewRequest("GET", "/?device_id=query", nil)
+	r.Header.Set("X-Api-Version", "3")
+	r.Header.Set("X-Device-Id", "tv-1")
+	r.Header.Set("X-Other", "ignored")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+
+	params, err := parser.Parse(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.GetInt("api_version") != 3 || params.Source("api_version") != SourceHeader {
+		t.Error("Expected the api version header, got:", params.Values)
+	}
+	if params.GetString("session_id") != "abc" || params.Source("session_id") != SourceCookie {
+		t.Error("Expected the session cookie, got:", params.Values)
+	}
+	if params.GetString("device_id") != "query" {
+		t.Error("Expected the query to take precedence over the headers, got:", params.GetString("device_id"))
+	}
+	if _, ok := params.Get("X-Other"); ok {
+		t.Error("Expected unmapped headers to be ignored")
+	}
+}
+
+type boundParams struct {
+	Name     string
+	DeviceID string `header:"X-Device-Id"`
+	Version  int    `header:"X-Api-Version" default:"1"`
+	Session  string `cookie:"session"`
+}
+
+func TestImbueHeadersAndCookies(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"name": "Ann", "device_id": "body", "session": "body"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Device-Id", "tv-1")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+
+	var obj boundParams
+	ParseParams(r).Imbue(&obj)
+	if obj != (boundParams{Name: "Ann", DeviceID: "tv-1", Version: 1, Session: "abc"}) {
+		t.Errorf("Unexpected struct: %+v", obj)
+	}
+
+	r.Header.Set("X-Api-Version", "v2")
+	if _, invalid := ParseParams(r).imbue(&obj); len(invalid) != 1 || invalid[0].key != "X-Api-Version" {
+		t.Error("Expected X-Api-Version to be invalid, got:", invalid)
+	}
+}
+
+func TestImbueDottedCookie(t *testing.T) {
+	type session struct {
+		ID   string `cookie:"session.id"`
+		Seen int    `cookie:"session.seen"`
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "session.id", Value: "abc"})
+	r.AddCookie(&http.Cookie{Name: "session.seen", Value: "3"})
+
+	var obj session
+	if err := ParseParams(r).Bind(&obj); err != nil {
+		t.Fatal(err)
+	}
+	if obj != (session{ID: "abc", Seen: 3}) {
+		t.Errorf("Unexpected struct: %+v", obj)
+	}
+}
+
+func TestOpenAPIBound(t *testing.T) {
+	defer func(saved []Route) { routes = saved }(routes)
+	routes = nil
+	RegisterRoute("GET", "/me", boundParams{})
+
+	data, err := OpenAPISpec("Me", "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Parameters []struct{ Name, In string }
+		}
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatal(err)
+	}
+	in := make(map[string]string)
+	for _, param := range spec.Paths["/me"]["get"].Parameters {
+		in[param.Name] = param.In
+	}
+	expected := map[string]string{"name": "query", "X-Device-Id": "header", "X-Api-Version": "header", "session": "cookie"}
+	if len(in) != len(expected) {
+		t.Fatal("Unexpected parameters:", in)
+	}
+	for name, want := range expected {
+		if in[name] != want {
+			t.Errorf("Expected %s in %s, got: %q", name, want, in[name])
+		}
+	}
+}
+
+func TestBindInvalidHeaderAndCookie(t *testing.T) {
+	type bound struct {
+		Version int    `header:"X-Api-Version" validate:"min=1"`
+		Visits  int    `cookie:"visits"`
+		Name    string `validate:"required"`
+	}
+	r := httptest.NewRequest("GET", "/?name=Ann", nil)
+	r.Header.Set("X-Api-Version", "v2")
+	r.AddCookie(&http.Cookie{Name: "visits", Value: "many"})
+
+	var obj bound
+	err := ParseParams(r).Bind(&obj)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatal("Expected two FieldErrors, got:", err)
+	}
+	expected := []FieldError{
+		{Field: "X-Api-Version", Tag: "type", Param: "int", Value: "v2"},
+		{Field: "visits", Tag: "type", Param: "int", Value: "many"},
+	}
+	for i, want := range expected {
+		if got := *errs[i]; got.Field != want.Field || got.Tag != want.Tag || got.Param != want.Param || got.Value != want.Value {
+			t.Errorf("Expected %+v, got: %+v", want, got)
+		}
+	}
+}
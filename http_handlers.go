@@ -1,35 +1,52 @@
 package parameters
 
 import (
-	"compress/gzip"
-	"io"
+	"bufio"
+	"errors"
+	"net"
 	"net/http"
-	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 )
 
 // CORSHeaders adds cross origin resource sharing headers to a response
+//
+// Deprecated: CORSHeaders allows credentialed requests from any origin, use a
+// CORSPolicy.
 func CORSHeaders(fn http.HandlerFunc) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
-		if origin := r.Header.Get("Origin"); origin != "" {
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-		}
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		setCORSHeaders(w, r)
 		fn(w, r)
 	}
 }
 
-// SendCORS sends a cross origin resource sharing header only
-func SendCORS(w http.ResponseWriter, req *http.Request) {
-	if origin := req.Header.Get("Origin"); origin != "" {
+// CORSHeadersHandler is the http.Handler flavor of CORSHeaders
+//
+// Deprecated: CORSHeadersHandler allows credentialed requests from any
+// origin, use the Handler of a CORSPolicy.
+func CORSHeadersHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w, r)
+		h.ServeHTTP(w, r)
+	})
+}
+
+func setCORSHeaders(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" {
 		w.Header().Set("Access-Control-Allow-Origin", origin)
 	}
 	w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token")
 	w.Header().Set("Access-Control-Allow-Credentials", "true")
+}
+
+// SendCORS sends a cross origin resource sharing header only
+//
+// Deprecated: SendCORS allows credentialed requests from any origin, use the
+// Preflight handler of a CORSPolicy.
+func SendCORS(w http.ResponseWriter, req *http.Request) {
+	setCORSHeaders(w, req)
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -41,66 +58,179 @@ func JSONResp(fn httprouter.Handle) httprouter.Handle {
 	}
 }
 
-// GeneralResponse calls the default wrappers: EnableGZIP, LogRequest,
-// CORSHeaders
+// JSONRespHandler is the http.Handler flavor of JSONResp
+func JSONRespHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		h.ServeHTTP(rw, req)
+	})
+}
+
+// DefaultCORSPolicy is the CORS step of the General* wrappers, read when a
+// handler is wrapped. When nil they use the deprecated CORSHeaders, which
+// allow credentialed requests from any origin.
+var DefaultCORSPolicy *CORSPolicy
+
+// GeneralResponse calls the default wrappers: EnableGZIP, LogRequest and
+// CORSHeaders, or DefaultCORSPolicy when set
 func GeneralResponse(fn http.HandlerFunc) httprouter.Handle {
-	return EnableGZIP(MakeHTTPRouterParsedReq(CORSHeaders(fn)))
+	return EnableGZIP(MakeHTTPRouterParsedReq(LogRequest(generalCORS(fn))))
 }
 
 // GeneralJSONRequest calls the default wrappers for a json response:
-// EnableGZIP, JSONResp, LogRequest, CORSHeaders
+// EnableGZIP, JSONResp, LogRequest and CORSHeaders, or DefaultCORSPolicy
+// when set
 func GeneralJSONResponse(fn http.HandlerFunc) httprouter.Handle {
-	return EnableGZIP(JSONResp(MakeHTTPRouterParsedReq(CORSHeaders(fn))))
+	return EnableGZIP(JSONResp(MakeHTTPRouterParsedReq(LogRequest(generalCORS(fn)))))
+}
+
+// GeneralHandler is the http.Handler flavor of GeneralResponse, for routers
+// like http.ServeMux:
+//
+//	mux.Handle("GET /users/{id}", parameters.GeneralHandler(http.HandlerFunc(show)))
+func GeneralHandler(h http.Handler) http.Handler {
+	return EnableGZIPHandler(ParsedHandler(LogRequestHandler(generalCORSHandler(h))))
+}
+
+// GeneralJSONHandler is the http.Handler flavor of GeneralJSONResponse
+func GeneralJSONHandler(h http.Handler) http.Handler {
+	return EnableGZIPHandler(JSONRespHandler(ParsedHandler(LogRequestHandler(generalCORSHandler(h)))))
+}
+
+// generalCORS applies DefaultCORSPolicy to fn, or CORSHeaders when it is nil
+func generalCORS(fn http.HandlerFunc) httprouter.Handle {
+	if DefaultCORSPolicy == nil {
+		return CORSHeaders(fn)
+	}
+	return DefaultCORSPolicy.Handle(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		fn(w, r)
+	})
+}
+
+// generalCORSHandler is the http.Handler flavor of generalCORS
+func generalCORSHandler(h http.Handler) http.Handler {
+	if DefaultCORSPolicy == nil {
+		return CORSHeadersHandler(h)
+	}
+	return DefaultCORSPolicy.Handler(h)
 }
 
 var filterReplace = [...]string{"FILTERED"}
 
-// FilteredKeys is a lower case array of keys to filter when logging
+// FilteredKeys is a lower case array of keys to filter when logging, at any
+// depth. Use DefaultRedactor to filter keys by pattern.
 var FilteredKeys []string
 
 // filterMap will filter the parameters and not log parameters with sensitive
-// data. To add more parameters - see the if in the loop
+// data, see DefaultRedactor
 func filterMap(params *Params) *Params {
 	var filtered Params
-	filtered.Values = make(map[string]interface{}, len(params.Values))
-
-	for k, v := range params.Values {
-		if contains(FilteredKeys, k) {
-			filtered.Values[k] = filterReplace[:]
-		} else if b, ok := v.([]byte); ok {
-			filtered.Values[k] = string(b)
-		} else {
-			filtered.Values[k] = v
-		}
-	}
+	filtered.Values = DefaultRedactor.Redact(params.Values)
 	return &filtered
 }
 
-type gzipResponseWriter struct {
-	io.Writer
+// loggingResponseWriter records the status and size of a response
+type loggingResponseWriter struct {
 	http.ResponseWriter
+	status int
+	bytes  int
 }
 
-func (w gzipResponseWriter) Write(b []byte) (int, error) {
-	if "" == w.Header().Get("Content-Type") {
-		// If no content type, apply sniffing algorithm to un-gzipped body.
-		w.Header().Set("Content-Type", http.DetectContentType(b))
+func (w *loggingResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
 	}
-	return w.Writer.Write(b)
+	w.ResponseWriter.WriteHeader(status)
 }
 
-// EnableGZIP will attempt to compress the response if the client has passed a
-// header value for Accept-Encoding which allows gzip
-func EnableGZIP(fn httprouter.Handle) httprouter.Handle {
+func (w *loggingResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Flush sends the buffered response, for streamed responses like server sent
+// events
+func (w *loggingResponseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack takes over the connection, which is logged as switching protocols
+func (w *loggingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("parameters: the response writer does not implement http.Hijacker")
+	}
+	conn, rw, err := hj.Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController
+func (w *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// logRequest serves a request with fn and logs it along with the params
+// parsed into its context, filtered by FilteredKeys, with the logger of their
+// parser
+func logRequest(w http.ResponseWriter, r *http.Request, fn func(http.ResponseWriter, *http.Request)) {
+	start := time.Now()
+	lw := &loggingResponseWriter{ResponseWriter: w}
+	fn(lw, r)
+	if lw.status == 0 {
+		lw.status = http.StatusOK
+	}
+
+	logger := defaultLogger()
+	attrs := []interface{}{
+		"method", r.Method,
+		"path", r.URL.Path,
+		"status", lw.status,
+		"bytes", lw.bytes,
+		"latency", time.Since(start),
+	}
+	if params, ok := r.Context().Value(ParamsKey).(*Params); ok {
+		logger = params.log()
+		attrs = append(attrs, "params", params)
+	}
+	logger.Info("request", attrs...)
+}
+
+// LogRequest logs the method, path, status, size and latency of a request
+// with its params. Wrap it in MakeHTTPRouterParsedReq to log the params.
+func LogRequest(fn httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
-		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
-			fn(w, r, p)
-			return
-		}
-		w.Header().Set("Content-Encoding", "gzip")
-		gz := gzip.NewWriter(w)
-		gzr := gzipResponseWriter{Writer: gz, ResponseWriter: w}
-		fn(gzr, r, p)
-		gz.Close()
+		logRequest(w, r, func(w http.ResponseWriter, r *http.Request) { fn(w, r, p) })
 	}
 }
+
+// LogRequestHandler is the net/http flavor of LogRequest
+func LogRequestHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logRequest(w, r, h.ServeHTTP)
+	})
+}
+
+// EnableGZIP will attempt to compress the response if the client has passed a
+// header value for Accept-Encoding which allows gzip, see DefaultCompressor
+func EnableGZIP(fn httprouter.Handle) httprouter.Handle {
+	return DefaultCompressor.handle(fn, encodings[:1])
+}
+
+// EnableGZIPHandler is the http.Handler flavor of EnableGZIP
+func EnableGZIPHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		DefaultCompressor.serve(w, r, encodings[:1], h.ServeHTTP)
+	})
+}
@@ -0,0 +1,190 @@
+package parameters
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestFilterMapNested(t *testing.T) {
+	defer func(keys []string) { FilteredKeys = keys }(FilteredKeys)
+	FilteredKeys = []string{"password", "token"}
+
+	params := &Params{Values: map[string]interface{}{
+		"Password": "secret",
+		"user": map[string]interface{}{
+			"name":  "bob",
+			"token": "abc",
+		},
+		"devices": []interface{}{
+			map[string]interface{}{"token": "def", "os": "ios"},
+		},
+	}}
+	filtered := filterMap(params).Values
+
+	expected := map[string]interface{}{
+		"Password": "FILTERED",
+		"user": map[string]interface{}{
+			"name":  "bob",
+			"token": "FILTERED",
+		},
+		"devices": []interface{}{
+			map[string]interface{}{"token": "FILTERED", "os": "ios"},
+		},
+	}
+	if !reflect.DeepEqual(filtered, expected) {
+		t.Error("Unexpected filtered values:", filtered)
+	}
+	if params.Values["user"].(map[string]interface{})["token"] != "abc" {
+		t.Error("Filtering should not change the params")
+	}
+}
+
+func TestLogRequest(t *testing.T) {
+	defer func(keys []string) { FilteredKeys = keys }(FilteredKeys)
+	FilteredKeys = []string{"password"}
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer SetLogger(nil)
+
+	handle := MakeHTTPRouterParsedReq(LogRequest(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	}))
+	r := httptest.NewRequest("POST", "/users?name=bob&password=secret", nil)
+	handle(httptest.NewRecorder(), r, nil)
+
+	line := buf.String()
+	for _, part := range []string{"method=POST path=/users status=201 bytes=5", "params.name=bob", "params.password=FILTERED"} {
+		if !strings.Contains(line, part) {
+			t.Errorf("Expected %q in log: %s", part, line)
+		}
+	}
+	if strings.Contains(line, "secret") {
+		t.Error("Filtered value was logged:", line)
+	}
+}
+
+func TestLogRequestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer SetLogger(nil)
+
+	h := LogRequestHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+	if line := buf.String(); !strings.Contains(line, "method=GET path=/health status=200 bytes=2") {
+		t.Error("Unexpected log:", line)
+	}
+}
+
+func TestGeneralJSONHandlerServeMux(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer SetLogger(nil)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{user_id}", Coercions{"user_id": CoerceUint64}.Handler(GeneralJSONHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := GetParams(r)
+		w.Write([]byte(`{"id": ` + strconv.FormatUint(params.GetUint64("user_id"), 10) + `, "pad": "` + strings.Repeat("x", 2048) + `"}`))
+	}))))
+
+	r := httptest.NewRequest("GET", "/users/42", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	r.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	if w.Header().Get("Content-Type") != "application/json" || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("Unexpected headers:", w.Header())
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Error("Expected the CORS headers:", w.Header())
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(gz); !strings.HasPrefix(string(data), `{"id": 42,`) {
+		t.Error("Unexpected body:", string(data))
+	}
+	if line := buf.String(); !strings.Contains(line, "path=/users/42 status=200") || !strings.Contains(line, "params.user_id=42") {
+		t.Error("Unexpected log:", line)
+	}
+}
+
+func TestGeneralHandlerFlush(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer SetLogger(nil)
+
+	h := GeneralHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: 1\n\n")
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("Expected the writer to implement http.Flusher")
+		}
+		f.Flush()
+		if _, ok := w.(http.Hijacker); !ok {
+			t.Error("Expected the writer to implement http.Hijacker")
+		}
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/events", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	h.ServeHTTP(w, r)
+	if !w.Flushed || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("Expected a flushed gzip response:", w.Header())
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(gz); string(data) != "data: 1\n\n" {
+		t.Error("Unexpected body:", string(data))
+	}
+	if line := buf.String(); !strings.Contains(line, "status=200") {
+		t.Error("Unexpected log:", line)
+	}
+
+	lw := &loggingResponseWriter{ResponseWriter: httptest.NewRecorder()}
+	if _, _, err := lw.Hijack(); err == nil {
+		t.Error("Expected an error hijacking a writer which is not a http.Hijacker")
+	}
+}
+
+func TestGeneralHandlerCORSPolicy(t *testing.T) {
+	DefaultCORSPolicy = &CORSPolicy{AllowedOrigins: []string{"https://app.example.com"}}
+	defer func() { DefaultCORSPolicy = nil }()
+
+	called := false
+	h := GeneralHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/users", nil)
+	r.Header.Set("Origin", "https://evil.com")
+	h.ServeHTTP(w, r)
+	if called || w.Code != http.StatusForbidden {
+		t.Error("Expected the origin to be rejected, got:", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.Header.Set("Origin", "https://app.example.com")
+	h.ServeHTTP(w, r)
+	if !called || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Error("Unexpected headers:", w.Header())
+	}
+}
@@ -0,0 +1,80 @@
+package parameters
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+)
+
+// DefaultItemsKey is the key of the items of a top level JSON array body when
+// the parser sets no ItemsKey
+const DefaultItemsKey = "items"
+
+func (ps *Parser) itemsKey() string {
+	if ps.ItemsKey != "" {
+		return ps.ItemsKey
+	}
+	return DefaultItemsKey
+}
+
+// decodeJSON decodes a JSON object body, or a top level array of objects
+// which sets the items of p and is stored under the items key of the parser
+func (ps *Parser) decodeJSON(p *Params, r io.Reader) (map[string]interface{}, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimLeft(raw, " \t\r\n"); len(trimmed) == 0 || trimmed[0] != '[' {
+		var body map[string]interface{}
+		err := json.Unmarshal(raw, &body)
+		return body, err
+	}
+
+	var values []map[string]interface{}
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+	items := make([]interface{}, len(values))
+	p.items = make([]*Params, len(values))
+	for i, v := range values {
+		items[i] = v
+		item := &Params{logger: p.logger, header: p.header, cookies: p.cookies}
+		item.setSource(SourceJSON, v)
+		item.merge(false)
+		p.items[i] = item
+	}
+	return map[string]interface{}{ps.itemsKey(): items}, nil
+}
+
+// Items returns the params of each object of a top level JSON array body, nil
+// for other bodies
+func (p *Params) Items() []*Params {
+	return p.items
+}
+
+// ImbueSlice sets a slice of structs, or of pointers to structs, to the
+// items of a top level JSON array body, see Imbue:
+//
+//	var users []User
+//	params.ImbueSlice(&users)
+func (p *Params) ImbueSlice(slice interface{}) {
+	sliceValue := reflect.ValueOf(slice).Elem()
+	elemType := sliceValue.Type().Elem()
+	structType := elemType
+	if elemType.Kind() == reflect.Ptr {
+		structType = elemType.Elem()
+	}
+
+	result := reflect.MakeSlice(sliceValue.Type(), 0, len(p.items))
+	for _, item := range p.items {
+		obj := reflect.New(structType)
+		item.Imbue(obj.Interface())
+		if elemType.Kind() == reflect.Ptr {
+			result = reflect.Append(result, obj)
+		} else {
+			result = reflect.Append(result, obj.Elem())
+		}
+	}
+	sliceValue.Set(result)
+}
@@ -0,0 +1,65 @@
+package parameters
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseJSONArray(t *testing.T) {
+	header := http.Header{"Content-Type": {"application/json"}}
+	params, err := DefaultParser.Parse(buildRequest(t, "POST", "/users?dry_run=true", strings.NewReader(`[{"id": 1, "name": "Ann"}, {"id": 2, "name": "Bob"}]`), header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := params.Items()
+	if len(items) != 2 || items[0].GetInt("id") != 1 || items[1].GetString("name") != "Bob" {
+		t.Fatal("Unexpected items:", items)
+	}
+	if items[1].Source("id") != SourceJSON {
+		t.Error("Unexpected source:", items[1].Source("id"))
+	}
+	if raw, ok := params.Values[DefaultItemsKey].([]interface{}); !ok || len(raw) != 2 {
+		t.Error("Expected the items under the items key, got:", params.Values)
+	}
+	if !params.GetBool("dry_run") {
+		t.Error("Expected the query to be parsed, got:", params.Values)
+	}
+
+	parser := &Parser{ItemsKey: "users"}
+	params, _ = parser.Parse(buildRequest(t, "POST", "/users?dry_run=true", strings.NewReader(`[{"id": 1}]`), header))
+	if _, ok := params.Get("users"); !ok {
+		t.Error("Expected the items under users, got:", params.Values)
+	}
+
+	params, _ = DefaultParser.Parse(buildRequest(t, "POST", "/users?dry_run=true", strings.NewReader(`{"id": 1}`), header))
+	if params.Items() != nil {
+		t.Error("Expected no items for an object body")
+	}
+	if _, err := DefaultParser.Parse(buildRequest(t, "POST", "/users?dry_run=true", strings.NewReader(`[1, 2]`), header)); err == nil {
+		t.Error("Expected an error for an array of numbers")
+	}
+}
+
+func TestImbueSlice(t *testing.T) {
+	header := http.Header{"Content-Type": {"application/json"}}
+	type user struct {
+		ID   int
+		Name string
+		Role string `default:"member"`
+	}
+	params := ParseParams(buildRequest(t, "POST", "/users?dry_run=true", strings.NewReader(`[{"id": 1, "name": "Ann"}, {"id": 2, "name": "Bob", "role": "admin"}]`), header))
+
+	var users []user
+	params.ImbueSlice(&users)
+	expected := []user{{1, "Ann", "member"}, {2, "Bob", "admin"}}
+	if len(users) != len(expected) || users[0] != expected[0] || users[1] != expected[1] {
+		t.Errorf("Unexpected users: %+v", users)
+	}
+
+	var ptrs []*user
+	params.ImbueSlice(&ptrs)
+	if len(ptrs) != 2 || *ptrs[1] != expected[1] {
+		t.Errorf("Unexpected users: %+v", ptrs)
+	}
+}
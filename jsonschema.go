@@ -0,0 +1,618 @@
+package parameters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// JSONSchema is a compiled JSON Schema document. The common draft 2020-12
+// keywords are supported: type, properties, required, items, enum, const,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf,
+// minLength, maxLength, pattern, format, minItems, maxItems,
+// additionalProperties, oneOf, anyOf, allOf and $ref within the document.
+//
+// Values are matched leniently so a contract validates params parsed from
+// JSON, form or msgpack alike: "5" is an integer and "a,b" an array.
+type JSONSchema struct {
+	root *jsonSchemaNode
+}
+
+type jsonSchemaNode struct {
+	// always is set for the boolean schemas true and false
+	always *bool
+
+	ref        string
+	refNode    *jsonSchemaNode
+	types      []string
+	properties map[string]*jsonSchemaNode
+	propOrder  []string
+	required   []string
+	additional *jsonSchemaNode
+	items      *jsonSchemaNode
+	enum       []interface{}
+	constVal   interface{}
+	hasConst   bool
+	format     string
+	pattern    *regexp.Regexp
+
+	minimum, maximum                   *float64
+	exclusiveMinimum, exclusiveMaximum *float64
+	multipleOf                         *float64
+	minLength, maxLength               *int
+	minItems, maxItems                 *int
+
+	allOf, anyOf, oneOf []*jsonSchemaNode
+}
+
+// jsonSchemaCompiler resolves $ref pointers against the document, each
+// pointer being compiled once so recursive schemas terminate
+type jsonSchemaCompiler struct {
+	doc   interface{}
+	nodes map[string]*jsonSchemaNode
+	refs  []*jsonSchemaNode
+}
+
+// CompileJSONSchema compiles a JSON Schema document. References must point
+// inside the document, nothing is fetched.
+func CompileJSONSchema(data []byte) (*JSONSchema, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	c := &jsonSchemaCompiler{doc: doc, nodes: make(map[string]*jsonSchemaNode)}
+	root, err := c.compile("", doc)
+	if err != nil {
+		return nil, err
+	}
+	// Resolving a reference may compile further references
+	for len(c.refs) > 0 {
+		pending := c.refs
+		c.refs = nil
+		for _, node := range pending {
+			if node.refNode, err = c.resolve(node.ref); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &JSONSchema{root: root}, nil
+}
+
+func (c *jsonSchemaCompiler) resolve(ref string) (*jsonSchemaNode, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("jsonschema: unsupported $ref %q, only references within the document are allowed", ref)
+	}
+	ptr := ref[1:]
+	if node, ok := c.nodes[ptr]; ok {
+		return node, nil
+	}
+
+	target := c.doc
+	if ptr != "" {
+		for _, token := range strings.Split(strings.TrimPrefix(ptr, "/"), "/") {
+			token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+			switch v := target.(type) {
+			case map[string]interface{}:
+				target = v[token]
+			case []interface{}:
+				i, err := strconv.Atoi(token)
+				if err != nil || i < 0 || i >= len(v) {
+					return nil, fmt.Errorf("jsonschema: $ref %q not found", ref)
+				}
+				target = v[i]
+			default:
+				target = nil
+			}
+			if target == nil {
+				return nil, fmt.Errorf("jsonschema: $ref %q not found", ref)
+			}
+		}
+	}
+
+	return c.compile(ptr, target)
+}
+
+func (c *jsonSchemaCompiler) compile(ptr string, raw interface{}) (*jsonSchemaNode, error) {
+	if node, ok := c.nodes[ptr]; ok {
+		return node, nil
+	}
+	node := &jsonSchemaNode{}
+	c.nodes[ptr] = node
+
+	if b, ok := raw.(bool); ok {
+		node.always = &b
+		return node, nil
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("jsonschema: %s is not a schema", pointerName(ptr))
+	}
+
+	var err error
+	sub := func(key string) (*jsonSchemaNode, error) {
+		return c.compile(ptr+"/"+escapePointer(key), m[key])
+	}
+	number := func(key string) (*float64, error) {
+		v, ok := m[key]
+		if !ok {
+			return nil, nil
+		}
+		f, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("jsonschema: %s/%s must be a number", pointerName(ptr), key)
+		}
+		return &f, nil
+	}
+	count := func(key string) (*int, error) {
+		f, err := number(key)
+		if f == nil || err != nil {
+			return nil, err
+		}
+		n := int(*f)
+		return &n, nil
+	}
+	list := func(key string) ([]*jsonSchemaNode, error) {
+		raw, ok := m[key]
+		if !ok {
+			return nil, nil
+		}
+		items, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("jsonschema: %s/%s must be an array", pointerName(ptr), key)
+		}
+		nodes := make([]*jsonSchemaNode, len(items))
+		for i, item := range items {
+			if nodes[i], err = c.compile(ptr+"/"+key+"/"+strconv.Itoa(i), item); err != nil {
+				return nil, err
+			}
+		}
+		return nodes, nil
+	}
+
+	if ref, ok := m["$ref"].(string); ok {
+		node.ref = ref
+		c.refs = append(c.refs, node)
+	}
+
+	switch t := m["type"].(type) {
+	case string:
+		node.types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				node.types = append(node.types, s)
+			}
+		}
+	}
+	for _, t := range node.types {
+		switch t {
+		case "null", "boolean", "integer", "number", "string", "array", "object":
+		default:
+			return nil, fmt.Errorf("jsonschema: %s has unknown type %q", pointerName(ptr), t)
+		}
+	}
+
+	if props, ok := m["properties"].(map[string]interface{}); ok {
+		node.properties = make(map[string]*jsonSchemaNode, len(props))
+		for name, raw := range props {
+			if node.properties[name], err = c.compile(ptr+"/properties/"+escapePointer(name), raw); err != nil {
+				return nil, err
+			}
+			node.propOrder = append(node.propOrder, name)
+		}
+		sort.Strings(node.propOrder)
+	}
+	if req, ok := m["required"].([]interface{}); ok {
+		for _, v := range req {
+			if s, ok := v.(string); ok {
+				node.required = append(node.required, s)
+			}
+		}
+	}
+	if _, ok := m["additionalProperties"]; ok {
+		if node.additional, err = sub("additionalProperties"); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := m["items"]; ok {
+		if node.items, err = sub("items"); err != nil {
+			return nil, err
+		}
+	}
+	if enum, ok := m["enum"].([]interface{}); ok {
+		node.enum = enum
+	}
+	node.constVal, node.hasConst = m["const"]
+	node.format, _ = m["format"].(string)
+	if pattern, ok := m["pattern"].(string); ok {
+		if node.pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("jsonschema: %s/pattern: %v", pointerName(ptr), err)
+		}
+	}
+
+	if node.minimum, err = number("minimum"); err != nil {
+		return nil, err
+	}
+	if node.maximum, err = number("maximum"); err != nil {
+		return nil, err
+	}
+	if node.exclusiveMinimum, err = number("exclusiveMinimum"); err != nil {
+		return nil, err
+	}
+	if node.exclusiveMaximum, err = number("exclusiveMaximum"); err != nil {
+		return nil, err
+	}
+	if node.multipleOf, err = number("multipleOf"); err != nil {
+		return nil, err
+	}
+	if node.minLength, err = count("minLength"); err != nil {
+		return nil, err
+	}
+	if node.maxLength, err = count("maxLength"); err != nil {
+		return nil, err
+	}
+	if node.minItems, err = count("minItems"); err != nil {
+		return nil, err
+	}
+	if node.maxItems, err = count("maxItems"); err != nil {
+		return nil, err
+	}
+
+	if node.allOf, err = list("allOf"); err != nil {
+		return nil, err
+	}
+	if node.anyOf, err = list("anyOf"); err != nil {
+		return nil, err
+	}
+	if node.oneOf, err = list("oneOf"); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+func pointerName(ptr string) string {
+	return "#" + ptr
+}
+
+// ValidateSchema validates the params against a compiled JSON Schema. Every
+// violation is returned in a ValidationErrors with its field set to a JSON
+// pointer.
+func (p *Params) ValidateSchema(s *JSONSchema) error {
+	return s.ValidateParams(p)
+}
+
+// ValidateParams validates the params against the schema, see ValidateSchema
+func (s *JSONSchema) ValidateParams(p *Params) error {
+	if s == nil || s.root == nil {
+		return errors.New("jsonschema: schema not compiled")
+	}
+	values := p.Values
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	var errs ValidationErrors
+	s.root.validate("", values, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// valid reports if val matches the node without recording the violations
+func (n *jsonSchemaNode) valid(val interface{}) bool {
+	var errs ValidationErrors
+	n.validate("", val, &errs)
+	return len(errs) == 0
+}
+
+func (n *jsonSchemaNode) validate(path string, val interface{}, errs *ValidationErrors) {
+	if n.always != nil {
+		if !*n.always {
+			schemaFail(errs, path, "unknown", "", val)
+		}
+		return
+	}
+	if n.refNode != nil {
+		n.refNode.validate(path, val, errs)
+	}
+
+	kind, view := jsonView(val)
+	if len(n.types) > 0 {
+		matched := false
+		for _, t := range n.types {
+			if v, ok := jsonViewAs(t, val); ok {
+				kind, view, matched = jsonKind(t), v, true
+				break
+			}
+		}
+		if !matched {
+			schemaFail(errs, path, "type", strings.Join(n.types, " or "), val)
+			return
+		}
+	}
+
+	if n.enum != nil {
+		found := false
+		for _, option := range n.enum {
+			if jsonEqual(view, option) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			schemaFail(errs, path, "oneof", jsonList(n.enum), val)
+		}
+	}
+	if n.hasConst && !jsonEqual(view, n.constVal) {
+		schemaFail(errs, path, "eq", jsonList([]interface{}{n.constVal}), val)
+	}
+
+	switch kind {
+	case "number":
+		n.validateNumber(path, view.(float64), errs)
+	case "string":
+		n.validateString(path, view.(string), errs)
+	case "array":
+		n.validateArray(path, view.([]interface{}), errs)
+	case "object":
+		n.validateObject(path, view.(map[string]interface{}), errs)
+	}
+
+	for _, sub := range n.allOf {
+		sub.validate(path, val, errs)
+	}
+	if n.anyOf != nil {
+		matched := false
+		for _, sub := range n.anyOf {
+			if sub.valid(val) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			schemaFail(errs, path, "anyof", "", val)
+		}
+	}
+	if n.oneOf != nil {
+		matched := 0
+		for _, sub := range n.oneOf {
+			if sub.valid(val) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			schemaFail(errs, path, "exactlyone", "", val)
+		}
+	}
+}
+
+func (n *jsonSchemaNode) validateNumber(path string, f float64, errs *ValidationErrors) {
+	format := func(f float64) string {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	if n.minimum != nil && f < *n.minimum {
+		schemaFail(errs, path, "min", format(*n.minimum), f)
+	}
+	if n.maximum != nil && f > *n.maximum {
+		schemaFail(errs, path, "max", format(*n.maximum), f)
+	}
+	if n.exclusiveMinimum != nil && f <= *n.exclusiveMinimum {
+		schemaFail(errs, path, "gt", format(*n.exclusiveMinimum), f)
+	}
+	if n.exclusiveMaximum != nil && f >= *n.exclusiveMaximum {
+		schemaFail(errs, path, "lt", format(*n.exclusiveMaximum), f)
+	}
+	if n.multipleOf != nil && *n.multipleOf > 0 {
+		if q := f / *n.multipleOf; q != math.Trunc(q) {
+			schemaFail(errs, path, "multipleof", format(*n.multipleOf), f)
+		}
+	}
+}
+
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func (n *jsonSchemaNode) validateString(path string, s string, errs *ValidationErrors) {
+	length := utf8.RuneCountInString(s)
+	if n.minLength != nil && length < *n.minLength {
+		schemaFail(errs, path, "min", strconv.Itoa(*n.minLength), s)
+	}
+	if n.maxLength != nil && length > *n.maxLength {
+		schemaFail(errs, path, "max", strconv.Itoa(*n.maxLength), s)
+	}
+	if n.pattern != nil && !n.pattern.MatchString(s) {
+		schemaFail(errs, path, "regex", n.pattern.String(), s)
+	}
+
+	// Unknown formats are annotations only
+	switch n.format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			schemaFail(errs, path, "format", n.format, s)
+		}
+	case "date":
+		if _, err := time.Parse(DateOnly, s); err != nil {
+			schemaFail(errs, path, "format", n.format, s)
+		}
+	case "email":
+		if !isEmail(s) {
+			schemaFail(errs, path, "email", "", s)
+		}
+	case "uuid":
+		if !uuidRe.MatchString(s) {
+			schemaFail(errs, path, "format", n.format, s)
+		}
+	}
+}
+
+func (n *jsonSchemaNode) validateArray(path string, items []interface{}, errs *ValidationErrors) {
+	if n.minItems != nil && len(items) < *n.minItems {
+		schemaFail(errs, path, "min", strconv.Itoa(*n.minItems), items)
+	}
+	if n.maxItems != nil && len(items) > *n.maxItems {
+		schemaFail(errs, path, "max", strconv.Itoa(*n.maxItems), items)
+	}
+	if n.items != nil {
+		for i, item := range items {
+			n.items.validate(path+"/"+strconv.Itoa(i), item, errs)
+		}
+	}
+}
+
+func (n *jsonSchemaNode) validateObject(path string, m map[string]interface{}, errs *ValidationErrors) {
+	for _, name := range n.required {
+		if _, ok := m[name]; !ok {
+			schemaFail(errs, path+"/"+escapePointer(name), "required", "", nil)
+		}
+	}
+	for _, name := range n.propOrder {
+		if val, ok := m[name]; ok {
+			n.properties[name].validate(path+"/"+escapePointer(name), val, errs)
+		}
+	}
+	if n.additional != nil {
+		extra := make([]string, 0)
+		for k := range m {
+			if _, ok := n.properties[k]; !ok {
+				extra = append(extra, k)
+			}
+		}
+		sort.Strings(extra)
+		for _, k := range extra {
+			n.additional.validate(path+"/"+escapePointer(k), m[k], errs)
+		}
+	}
+}
+
+// jsonKind maps a schema type to the kind of its view, integers being
+// checked as numbers
+func jsonKind(t string) string {
+	if t == "integer" {
+		return "number"
+	}
+	return t
+}
+
+// jsonView returns the JSON kind of a Go value and its JSON representation
+func jsonView(val interface{}) (string, interface{}) {
+	switch v := val.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return "boolean", v
+	case string:
+		return "string", v
+	case []byte:
+		return "string", string(v)
+	case time.Time:
+		return "string", v.Format(time.RFC3339Nano)
+	case map[string]interface{}:
+		return "object", v
+	}
+	if f, ok := toFloat64(val); ok {
+		return "number", f
+	}
+	if items, ok := toSlice(val); ok {
+		return "array", items
+	}
+	return "", val
+}
+
+// jsonViewAs converts val to the JSON representation of the schema type t,
+// accepting the string forms of form values
+func jsonViewAs(t string, val interface{}) (interface{}, bool) {
+	switch t {
+	case "null":
+		return nil, val == nil
+	case "boolean":
+		switch v := val.(type) {
+		case bool:
+			return v, true
+		case string:
+			b, err := strconv.ParseBool(v)
+			return b, err == nil && (v == "true" || v == "false")
+		}
+	case "integer":
+		if n, ok := toInt64(val); ok {
+			return float64(n), true
+		}
+	case "number":
+		if _, isBool := val.(bool); !isBool {
+			return toFloat64(val)
+		}
+	case "string":
+		switch v := val.(type) {
+		case string:
+			return v, true
+		case []byte:
+			return string(v), true
+		case time.Time:
+			return v.Format(time.RFC3339Nano), true
+		case bool:
+			// Form values of true and false are converted to booleans when
+			// parsed
+			return strconv.FormatBool(v), true
+		}
+	case "array":
+		if items, ok := toSlice(val); ok {
+			return items, true
+		}
+	case "object":
+		if m, ok := val.(map[string]interface{}); ok {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// jsonEqual compares two values by their JSON representation
+func jsonEqual(a, b interface{}) bool {
+	_, av := jsonView(a)
+	_, bv := jsonView(b)
+	switch at := av.(type) {
+	case []interface{}:
+		bt, ok := bv.([]interface{})
+		if !ok || len(at) != len(bt) {
+			return false
+		}
+		for i := range at {
+			if !jsonEqual(at[i], bt[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		bt, ok := bv.(map[string]interface{})
+		if !ok || len(at) != len(bt) {
+			return false
+		}
+		for k, v := range at {
+			if w, ok := bt[k]; !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+func jsonList(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		if b, err := json.Marshal(v); err == nil {
+			parts[i] = string(b)
+		} else {
+			parts[i] = fmt.Sprint(v)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,169 @@
+package parameters
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testJSONSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["name", "age"],
+	"properties": {
+		"name": {"type": "string", "minLength": 1, "maxLength": 10},
+		"age": {"type": "integer", "minimum": 0, "exclusiveMaximum": 150},
+		"email": {"type": "string", "format": "email"},
+		"id": {"type": "string", "format": "uuid"},
+		"order": {"enum": ["asc", "desc"]},
+		"tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}, "maxItems": 3},
+		"active": {"type": "boolean"},
+		"address": {"$ref": "#/$defs/address"},
+		"contact": {"oneOf": [
+			{"type": "object", "required": ["phone"]},
+			{"type": "object", "required": ["fax"]}
+		]}
+	},
+	"additionalProperties": false,
+	"$defs": {
+		"address": {
+			"type": "object",
+			"properties": {"zip": {"type": "string", "pattern": "^[0-9]{5}$"}},
+			"required": ["zip"]
+		}
+	}
+}`
+
+func TestJSONSchemaValidJSON(t *testing.T) {
+	s, err := CompileJSONSchema([]byte(testJSONSchema))
+	if err != nil {
+		t.Fatal("Could not compile schema", err)
+	}
+
+	body := `{
+		"name": "Ann",
+		"age": 42,
+		"email": "ann@example.com",
+		"id": "3f1c6b0e-8f3a-4b6e-9d57-2a4c9f0e1d2b",
+		"order": "asc",
+		"tags": ["a", "b"],
+		"active": true,
+		"address": {"zip": "12345"},
+		"contact": {"phone": "555"}
+	}`
+	r, err := http.NewRequest("POST", "test", strings.NewReader(body))
+	if err != nil {
+		t.Fatal("Could not build request", err)
+	}
+	r.Header.Set("Content-Type", "application/json")
+
+	if err := ParseParams(r).ValidateSchema(s); err != nil {
+		t.Fatal("Expected no errors, got:", err)
+	}
+}
+
+func TestJSONSchemaValidForm(t *testing.T) {
+	s, err := CompileJSONSchema([]byte(testJSONSchema))
+	if err != nil {
+		t.Fatal("Could not compile schema", err)
+	}
+
+	form := url.Values{}
+	form.Set("name", "Ann")
+	form.Set("age", "42")
+	form.Set("tags", "a,b")
+	form.Set("active", "true")
+	r, err := http.NewRequest("POST", "test", strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal("Could not build request", err)
+	}
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if err := ParseParams(r).ValidateSchema(s); err != nil {
+		t.Fatal("Expected no errors, got:", err)
+	}
+}
+
+func TestJSONSchemaViolations(t *testing.T) {
+	s, err := CompileJSONSchema([]byte(testJSONSchema))
+	if err != nil {
+		t.Fatal("Could not compile schema", err)
+	}
+
+	params := &Params{Values: map[string]interface{}{
+		"age":     200.0,
+		"email":   "nope",
+		"id":      "1234",
+		"order":   "up",
+		"tags":    []interface{}{"a", "B", "c", "d"},
+		"address": map[string]interface{}{"zip": "abc"},
+		"contact": map[string]interface{}{"phone": "555", "fax": "555"},
+		"extra":   1.0,
+	}}
+
+	err = params.ValidateSchema(s)
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatal("Expected ValidationErrors, got:", err)
+	}
+
+	got := make([]string, len(errs))
+	for i, e := range errs {
+		got[i] = e.Field + ":" + e.Tag
+	}
+	expected := []string{
+		"/name:required",
+		"/address/zip:regex",
+		"/age:lt",
+		"/contact:exactlyone",
+		"/email:email",
+		"/id:format",
+		"/order:oneof",
+		"/tags:max",
+		"/tags/1:regex",
+		"/extra:unknown",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestCompileJSONSchemaErrors(t *testing.T) {
+	schemas := []string{
+		`{"type": "text"}`,
+		`{"$ref": "https://example.com/schema.json"}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"pattern": "("}`,
+		`[]`,
+	}
+	for _, schema := range schemas {
+		if _, err := CompileJSONSchema([]byte(schema)); err == nil {
+			t.Errorf("Expected an error compiling %s", schema)
+		}
+	}
+}
+
+func TestJSONSchemaRecursiveRef(t *testing.T) {
+	s, err := CompileJSONSchema([]byte(`{
+		"$ref": "#/$defs/node",
+		"$defs": {
+			"node": {
+				"type": "object",
+				"properties": {"child": {"$ref": "#/$defs/node"}, "n": {"type": "integer"}}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal("Could not compile schema", err)
+	}
+
+	params := &Params{Values: map[string]interface{}{
+		"child": map[string]interface{}{"child": map[string]interface{}{"n": "x"}},
+	}}
+	errs, ok := params.ValidateSchema(s).(ValidationErrors)
+	if !ok || len(errs) != 1 || errs[0].Field != "/child/child/n" {
+		t.Fatal("Expected an error at /child/child/n, got:", errs)
+	}
+}
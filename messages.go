@@ -0,0 +1,181 @@
+package parameters
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed locales/*.json
+var defaultLocales embed.FS
+
+// Messages is the catalog used to render validation and type errors. It is
+// loaded with the English messages, load more locales to translate them:
+//
+//	//go:embed locales/*.json
+//	var locales embed.FS
+//
+//	err := parameters.Messages.LoadFS(locales, "locales")
+var Messages = NewCatalog("en")
+
+func init() {
+	if err := Messages.LoadFS(defaultLocales, "locales"); err != nil {
+		panic(err)
+	}
+}
+
+// Catalog holds error messages per locale. Messages are keyed by error code,
+// the tag of a FieldError, and can be specialized for a field by keying them
+// code.field. The placeholders {field} and {param} are replaced, a field
+// being named by the fields.name entry when there is one:
+//
+//	{
+//		"required": "{field} is required",
+//		"required.email": "We need your email address",
+//		"fields.email": "Email address"
+//	}
+type Catalog struct {
+	// Fallback is the locale used when no accepted language is available
+	Fallback string
+
+	mu       sync.RWMutex
+	messages map[string]map[string]string
+}
+
+// NewCatalog returns an empty catalog falling back to the given locale
+func NewCatalog(fallback string) *Catalog {
+	return &Catalog{
+		Fallback: fallback,
+		messages: make(map[string]map[string]string),
+	}
+}
+
+// Add merges messages into the given locale
+func (c *Catalog) Add(locale string, messages map[string]string) {
+	locale = strings.ToLower(locale)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	existing, ok := c.messages[locale]
+	if !ok {
+		existing = make(map[string]string, len(messages))
+		c.messages[locale] = existing
+	}
+	for k, v := range messages {
+		existing[k] = v
+	}
+}
+
+// LoadFS adds the JSON files of dir in fsys, each file holding the messages
+// of the locale it is named after: pt-BR.json
+func (c *Catalog) LoadFS(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return err
+		}
+		c.Add(strings.TrimSuffix(path.Base(file), ".json"), messages)
+	}
+	return nil
+}
+
+// Locale returns the available locale best matching an Accept-Language
+// header, trying each language by quality before its base language:
+//
+//	pt-BR;q=0.9, en;q=0.8 -> pt-br, pt, en
+func (c *Catalog) Locale(acceptLanguage string) string {
+	type language struct {
+		tag string
+		q   float64
+	}
+	var langs []language
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			langs = append(langs, language{tag, q})
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, lang := range langs {
+		if _, ok := c.messages[lang.tag]; ok {
+			return lang.tag
+		}
+		if i := strings.IndexByte(lang.tag, '-'); i > 0 {
+			if _, ok := c.messages[lang.tag[:i]]; ok {
+				return lang.tag[:i]
+			}
+		}
+	}
+	return strings.ToLower(c.Fallback)
+}
+
+// lookup returns the message for the first of keys found in locale, its base
+// language or the fallback locale
+func (c *Catalog) lookup(locale string, keys ...string) (string, bool) {
+	locale = strings.ToLower(locale)
+	candidates := []string{locale}
+	if i := strings.IndexByte(locale, '-'); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	candidates = append(candidates, strings.ToLower(c.Fallback))
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, candidate := range candidates {
+		for _, key := range keys {
+			if msg, ok := c.messages[candidate][key]; ok {
+				return msg, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Message renders the message of code for field in locale, an empty locale
+// being the fallback
+func (c *Catalog) Message(locale, code, field, param string) string {
+	msg, ok := c.lookup(locale, code+"."+field, code)
+	if !ok {
+		msg = "{field} failed on " + code
+	}
+
+	label := field
+	if name, ok := c.lookup(locale, "fields."+field); ok {
+		label = name
+	}
+	return strings.NewReplacer("{field}", label, "{param}", param).Replace(msg)
+}
+
+// Localize renders a field error in locale
+func (c *Catalog) Localize(err *FieldError, locale string) string {
+	return c.Message(locale, err.Tag, err.Field, err.Param)
+}
@@ -0,0 +1,96 @@
+package parameters
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func testCatalog(t *testing.T) *Catalog {
+	c := NewCatalog("en")
+	fsys := fstest.MapFS{
+		"i18n/en.json": {Data: []byte(`{"required": "{field} is required"}`)},
+		"i18n/pt.json": {Data: []byte(`{
+			"required": "{field} é obrigatório",
+			"min": "{field} deve ser no mínimo {param}",
+			"fields.name": "O nome"
+		}`)},
+		"i18n/pt-BR.json": {Data: []byte(`{"required.email": "Informe seu e-mail"}`)},
+	}
+	if err := c.LoadFS(fsys, "i18n"); err != nil {
+		t.Fatal("Could not load catalog", err)
+	}
+	return c
+}
+
+func TestCatalogLocale(t *testing.T) {
+	c := testCatalog(t)
+
+	entries := map[string]string{
+		"":                       "en",
+		"pt-BR":                  "pt-br",
+		"pt-PT":                  "pt",
+		"de, pt;q=0.8":           "pt",
+		"en;q=0.5, pt-BR;q=0.9":  "pt-br",
+		"fr, *":                  "en",
+		"pt;q=0, en":             "en",
+		"PT-br;q=0.7, de;q=0.95": "pt-br",
+	}
+	for header, expected := range entries {
+		if locale := c.Locale(header); locale != expected {
+			t.Errorf(`Expected "%s" to select "%s", not "%s"`, header, expected, locale)
+		}
+	}
+}
+
+func TestCatalogMessage(t *testing.T) {
+	c := testCatalog(t)
+
+	entries := []struct {
+		locale, code, field, param, expected string
+	}{
+		{"pt", "required", "name", "", "O nome é obrigatório"},
+		{"pt-br", "required", "email", "", "Informe seu e-mail"},
+		{"pt-br", "required", "age", "", "age é obrigatório"},
+		{"pt", "min", "age", "18", "age deve ser no mínimo 18"},
+		{"en", "required", "name", "", "name is required"},
+		{"de", "required", "name", "", "name is required"},
+		{"en", "min", "age", "18", "age failed on min"},
+	}
+	for _, e := range entries {
+		if msg := c.Message(e.locale, e.code, e.field, e.param); msg != e.expected {
+			t.Errorf(`Expected "%s", got "%s"`, e.expected, msg)
+		}
+	}
+}
+
+func TestWriteProblemLocalized(t *testing.T) {
+	defaultMessages := Messages
+	defer func() { Messages = defaultMessages }()
+	Messages = testCatalog(t)
+
+	r := httptest.NewRequest("POST", "/users", nil)
+	r.Header.Set("Accept-Language", "pt-BR, en;q=0.5")
+	w := httptest.NewRecorder()
+
+	WriteProblem(w, r, ValidationErrors{{Field: "email", Tag: "required"}})
+
+	if lang := w.Header().Get("Content-Language"); lang != "pt-br" {
+		t.Error("Unexpected content language:", lang)
+	}
+	var problem Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatal("Could not decode problem", err)
+	}
+	if len(problem.Errors) != 1 || problem.Errors[0].Detail != "Informe seu e-mail" {
+		t.Errorf("Unexpected problem: %+v", problem)
+	}
+}
+
+func TestDefaultMessages(t *testing.T) {
+	err := &FieldError{Field: "limit", Tag: "max", Param: "100"}
+	if msg := err.Error(); msg != "limit must be at most 100" {
+		t.Error("Unexpected message:", msg)
+	}
+}
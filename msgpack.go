@@ -0,0 +1,67 @@
+package parameters
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/ugorji/go/codec"
+)
+
+// decodeMsgpack decodes a msgpack body, either a map or a sequence of arrays
+// of key value pairs. Timestamps, of the msgpack timestamp extension, are
+// decoded as time.Time.
+func decodeMsgpack(data []byte) (map[string]interface{}, error) {
+	var mh codec.MsgpackHandle
+	mh.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	body := make(map[string]interface{})
+	if len(data) == 0 {
+		return body, nil
+	}
+
+	dec := codec.NewDecoderBytes(data, &mh)
+	if first := data[0]; (first >= 0x80 && first <= 0x8f) || first == 0xde || first == 0xdf {
+		if err := dec.Decode(&body); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		return body, nil
+	}
+
+	for dec.NumBytesRead() < len(data) {
+		var vals []interface{}
+		if err := dec.Decode(&vals); err != nil {
+			// The data ends within an array
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if len(vals)%2 != 0 {
+			return nil, fmt.Errorf("parameters: msgpack array of %d values is not made of key value pairs", len(vals))
+		}
+		// The first of the pairs of an array with the same key wins
+		for i := len(vals) - 2; i >= 0; i -= 2 {
+			key, ok := msgpackKey(vals[i])
+			if !ok {
+				return nil, fmt.Errorf("parameters: msgpack key %v is neither a str nor a bin", vals[i])
+			}
+			body[key] = vals[i+1]
+		}
+	}
+	return body, nil
+}
+
+// msgpackKey converts a str or bin key, which are both decoded as []byte
+// unless the handle sets RawToString
+func msgpackKey(key interface{}) (string, bool) {
+	switch key := key.(type) {
+	case string:
+		return key, true
+	case []byte:
+		return string(key), true
+	}
+	return "", false
+}
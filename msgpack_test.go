@@ -0,0 +1,75 @@
+package parameters
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ugorji/go/codec"
+)
+
+// encodeMsgpack encodes values one after the other like a msgpack body
+func encodeMsgpack(t *testing.T, values ...interface{}) []byte {
+	mh := codec.MsgpackHandle{WriteExt: true}
+	var buf bytes.Buffer
+	enc := codec.NewEncoder(&buf, &mh)
+	for _, v := range values {
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestParseMsgpackPairs(t *testing.T) {
+	header := http.Header{"Content-Type": {"application/x-msgpack"}}
+	at := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	body := encodeMsgpack(t,
+		[]interface{}{"name", "Ann", []byte("id"), 7, "name", "ignored"},
+		[]interface{}{"at", at},
+	)
+	params, err := DefaultParser.Parse(buildRequest(t, "POST", "/", bytes.NewReader(body), header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.GetString("name") != "Ann" || params.GetInt("id") != 7 {
+		t.Error("Unexpected params:", params.Values)
+	}
+	if got, ok := params.GetTimeOk("at"); !ok || !got.Equal(at) {
+		t.Error("Expected the timestamp, got:", params.Values["at"])
+	}
+	if params.Source("at") != SourceMsgpack {
+		t.Error("Unexpected source:", params.Source("at"))
+	}
+
+	body = encodeMsgpack(t, map[string]interface{}{"at": at})
+	params, err = DefaultParser.Parse(buildRequest(t, "POST", "/", bytes.NewReader(body), header))
+	if got, ok := params.GetTimeOk("at"); err != nil || !ok || !got.Equal(at) {
+		t.Error("Expected the timestamp of a map, got:", params.Values["at"], err)
+	}
+}
+
+func TestParseMsgpackPairsErrors(t *testing.T) {
+	pairs := encodeMsgpack(t, []interface{}{"name", "Ann"})
+	cases := map[string][]byte{
+		"odd length":      encodeMsgpack(t, []interface{}{"name", "Ann", "id"}),
+		"int key":         encodeMsgpack(t, []interface{}{1, "Ann"}),
+		"not pairs":       encodeMsgpack(t, []interface{}{"name", "Ann"}, 42),
+		"truncated":       pairs[:len(pairs)-2],
+		"truncated str":   {0x92, 0xa1},
+		"truncated array": {0x92, 0xa1, 0x61},
+		"truncated map":   {0x81, 0xa1, 0x61},
+	}
+
+	for name, data := range cases {
+		r := buildRequest(t, "POST", "/", bytes.NewReader(data), http.Header{"Content-Type": {"application/x-msgpack"}})
+		params, err := DefaultParser.Parse(r)
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if len(params.Values) != 0 {
+			t.Errorf("%s: expected no values, got: %v", name, params.Values)
+		}
+	}
+}
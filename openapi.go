@@ -0,0 +1,400 @@
+package parameters
+
+import (
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	typeOfBytes         = reflect.TypeOf([]byte(nil))
+	typeOfPtrToFileHead = reflect.TypeOf((*multipart.FileHeader)(nil))
+)
+
+// OpenAPIFor returns the OpenAPI 3.1 schema of the params accepted by Imbue
+// and Bind for obj, a struct or pointer to one. Properties are named with
+// CamelToSnakeCase and described by the default and validate tags:
+//
+//	Limit int `default:"25" validate:"min=1,max=100"`
+//
+// becomes
+//
+//	"limit": {"type": "integer", "default": 25, "minimum": 1, "maximum": 100}
+func OpenAPIFor(obj interface{}) ([]byte, error) {
+	return json.Marshal(openAPISchema(reflect.TypeOf(obj)))
+}
+
+func openAPISchema(t reflect.Type) map[string]interface{} {
+	return openAPITypeSchema(t, make(map[reflect.Type]bool))
+}
+
+// openAPITypeSchema describes t, seen holding the structs being described: a
+// struct nested in itself is described as an object without properties
+func openAPITypeSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr && t != typeOfPtrToTime && t != typeOfPtrToFileHead {
+		t = t.Elem()
+	}
+
+	switch t {
+	case typeOfTime, typeOfPtrToTime:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case typeOfBytes:
+		return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+	case typeOfPtrToFileHead:
+		return map[string]interface{}{"type": "string", "format": "binary"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64", "minimum": 0}
+	case reflect.Float32:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": openAPITypeSchema(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object"}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		props := make(map[string]interface{})
+		var required []string
+		openAPIFields(t, props, &required, seen)
+		schema := map[string]interface{}{"type": "object", "properties": props}
+		if len(required) > 0 {
+			sort.Strings(required)
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]interface{}{}
+}
+
+// openAPIFields adds the fields of t bound by Imbue to props
+func openAPIFields(t reflect.Type, props map[string]interface{}, required *[]string, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			openAPIFields(field.Type, props, required, seen)
+			continue
+		}
+
+		// Fields bound to a header or cookie are described by openAPIBound
+		if _, _, bound := boundTag(field); bound {
+			continue
+		}
+
+		// Imbue can only bind fields whose name survives the round trip
+		key := CamelToSnakeCase(field.Name)
+		if SnakeToCamelCase(key, true) != field.Name {
+			continue
+		}
+
+		schema := openAPITypeSchema(field.Type, seen)
+		if def, ok := field.Tag.Lookup("default"); ok {
+			schema["default"] = openAPIValue(schema, def)
+		}
+		for _, rule := range parseRules(field.Tag.Get("validate")) {
+			if rule.tag == "required" {
+				*required = append(*required, key)
+				continue
+			}
+			openAPIRule(schema, rule)
+		}
+		props[key] = schema
+	}
+}
+
+// openAPIBound describes the fields bound to a header or cookie as
+// parameters
+func openAPIBound(t reflect.Type) []interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	params := make([]interface{}, 0)
+	if t.Kind() != reflect.Struct {
+		return params
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			params = append(params, openAPIBound(field.Type)...)
+			continue
+		}
+		tag, name, ok := boundTag(field)
+		if !ok || field.PkgPath != "" {
+			continue
+		}
+		required := false
+		for _, rule := range parseRules(field.Tag.Get("validate")) {
+			required = required || rule.tag == "required"
+		}
+		params = append(params, map[string]interface{}{
+			"name":     name,
+			"in":       tag,
+			"required": required,
+			"schema":   openAPISchema(field.Type),
+		})
+	}
+	return params
+}
+
+// openAPIRule describes a validation rule with the matching schema keywords,
+// rules comparing fields have no equivalent
+func openAPIRule(schema map[string]interface{}, rule validationRule) {
+	limit := func() interface{} {
+		n, _ := strconv.ParseFloat(rule.param, 64)
+		return n
+	}
+	var minKey, maxKey string
+	switch schema["type"] {
+	case "string":
+		minKey, maxKey = "minLength", "maxLength"
+	case "array":
+		minKey, maxKey = "minItems", "maxItems"
+	case "object":
+		minKey, maxKey = "minProperties", "maxProperties"
+	default:
+		minKey, maxKey = "minimum", "maximum"
+	}
+
+	switch rule.tag {
+	case "min":
+		schema[minKey] = limit()
+	case "max":
+		schema[maxKey] = limit()
+	case "len":
+		if minKey == "minimum" {
+			schema["const"] = limit()
+		} else {
+			schema[minKey], schema[maxKey] = limit(), limit()
+		}
+	case "oneof":
+		options := strings.Fields(rule.param)
+		enum := make([]interface{}, len(options))
+		for i, option := range options {
+			enum[i] = openAPIValue(schema, option)
+		}
+		schema["enum"] = enum
+	case "email":
+		schema["format"] = "email"
+	case "regex":
+		schema["pattern"] = rule.param
+	}
+}
+
+// openAPIValue converts a tag value to the type of the schema
+func openAPIValue(schema map[string]interface{}, raw string) interface{} {
+	switch schema["type"] {
+	case "integer":
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(raw, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		var values []interface{}
+		for _, item := range strings.Split(raw, ",") {
+			values = append(values, openAPIValue(items, item))
+		}
+		return values
+	}
+	return raw
+}
+
+// Route documents the params accepted by a handler
+type Route struct {
+	Method string
+	Path   string
+	Params interface{}
+}
+
+var (
+	routesMu sync.Mutex
+	routes   []Route
+)
+
+// RegisterRoute records the params struct of a route for OpenAPISpec. The
+// path may use the gorilla/mux, httprouter or ServeMux syntax:
+//
+//	parameters.RegisterRoute("GET", "/users/{id}", UserQuery{})
+func RegisterRoute(method, path string, params interface{}) {
+	routesMu.Lock()
+	defer routesMu.Unlock()
+	routes = append(routes, Route{Method: strings.ToUpper(method), Path: path, Params: params})
+}
+
+// Routes returns the registered routes
+func Routes() []Route {
+	routesMu.Lock()
+	defer routesMu.Unlock()
+	return append([]Route(nil), routes...)
+}
+
+var pathParamRe = regexp.MustCompile(`\{([^}:.]+)(?::[^}]*)?(?:\.\.\.)?\}|[:*]([^/]+)`)
+
+// openAPIPath converts the path syntax of the routers to OpenAPI's and
+// returns the names of the path params
+func openAPIPath(path string) (string, []string) {
+	var names []string
+	converted := pathParamRe.ReplaceAllStringFunc(path, func(match string) string {
+		sub := pathParamRe.FindStringSubmatch(match)
+		name := sub[1]
+		if name == "" {
+			name = sub[2]
+		}
+		names = append(names, name)
+		return "{" + name + "}"
+	})
+	return converted, names
+}
+
+// OpenAPISpec assembles an OpenAPI 3.1 document from the registered routes.
+// Params are query parameters for GET, HEAD and DELETE and a request body
+// otherwise, path params being taken out of either.
+func OpenAPISpec(title, version string) ([]byte, error) {
+	paths := make(map[string]interface{})
+	for _, route := range Routes() {
+		path, names := openAPIPath(route.Path)
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = openAPIOperation(route, names)
+	}
+
+	spec := map[string]interface{}{
+		"openapi": "3.1.0",
+		"info":    map[string]interface{}{"title": title, "version": version},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{"Problem": openAPISchema(reflect.TypeOf(Problem{}))},
+		},
+	}
+	return json.MarshalIndent(spec, "", "  ")
+}
+
+func openAPIOperation(route Route, pathNames []string) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	if route.Params != nil {
+		schema = openAPISchema(reflect.TypeOf(route.Params))
+	}
+	props, _ := schema["properties"].(map[string]interface{})
+	required, _ := schema["required"].([]string)
+	isRequired := func(name string) bool {
+		for _, r := range required {
+			if r == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	params := make([]interface{}, 0)
+	for _, name := range pathNames {
+		param := map[string]interface{}{"name": name, "in": "path", "required": true}
+		if prop, ok := props[name]; ok {
+			param["schema"] = prop
+			delete(props, name)
+		} else {
+			param["schema"] = map[string]interface{}{"type": "string"}
+		}
+		params = append(params, param)
+	}
+	if route.Params != nil {
+		params = append(params, openAPIBound(reflect.TypeOf(route.Params))...)
+	}
+
+	op := map[string]interface{}{
+		"responses": map[string]interface{}{
+			strconv.Itoa(http.StatusUnprocessableEntity): map[string]interface{}{
+				"description": "Invalid parameters",
+				"content": map[string]interface{}{
+					"application/problem+json": map[string]interface{}{
+						"schema": map[string]interface{}{"$ref": "#/components/schemas/Problem"},
+					},
+				},
+			},
+		},
+	}
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	switch route.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		for _, name := range names {
+			params = append(params, map[string]interface{}{
+				"name":     name,
+				"in":       "query",
+				"required": isRequired(name),
+				"schema":   props[name],
+			})
+		}
+	default:
+		if len(props) > 0 {
+			bodyRequired := make([]string, 0)
+			binary := false
+			for _, name := range names {
+				if isRequired(name) {
+					bodyRequired = append(bodyRequired, name)
+				}
+				if prop, _ := props[name].(map[string]interface{}); prop["format"] == "binary" {
+					binary = true
+				}
+			}
+			body := map[string]interface{}{"type": "object", "properties": props}
+			if len(bodyRequired) > 0 {
+				body["required"] = bodyRequired
+			}
+			content := map[string]interface{}{
+				"multipart/form-data": map[string]interface{}{"schema": body},
+			}
+			if !binary {
+				content["application/json"] = map[string]interface{}{"schema": body}
+				content["application/x-www-form-urlencoded"] = map[string]interface{}{"schema": body}
+			}
+			op["requestBody"] = map[string]interface{}{
+				"required": len(bodyRequired) > 0,
+				"content":  content,
+			}
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	return op
+}
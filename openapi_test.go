@@ -0,0 +1,137 @@
+package parameters
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type openAPIQuery struct {
+	UserID uint64
+	Limit  int      `default:"25" validate:"min=1,max=100"`
+	Order  string   `validate:"required,oneof=asc desc"`
+	Tags   []string `validate:"max=3"`
+	Email  string   `validate:"email"`
+	IDs    []int
+}
+
+func TestOpenAPIFor(t *testing.T) {
+	data, err := OpenAPIFor(&openAPIQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	props := schema["properties"].(map[string]interface{})
+	if _, ok := props["ids"]; ok {
+		t.Error("Expected ids to be skipped, Imbue cannot bind it")
+	}
+
+	expected := map[string]interface{}{
+		"user_id": map[string]interface{}{"type": "integer", "format": "int64", "minimum": 0.0},
+		"limit":   map[string]interface{}{"type": "integer", "format": "int64", "default": 25.0, "minimum": 1.0, "maximum": 100.0},
+		"order":   map[string]interface{}{"type": "string", "enum": []interface{}{"asc", "desc"}},
+		"tags":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "maxItems": 3.0},
+		"email":   map[string]interface{}{"type": "string", "format": "email"},
+	}
+	for key, want := range expected {
+		if !reflect.DeepEqual(props[key], want) {
+			t.Errorf("Unexpected schema of %s: %v", key, props[key])
+		}
+	}
+	if !reflect.DeepEqual(schema["required"], []interface{}{"order"}) {
+		t.Error("Unexpected required:", schema["required"])
+	}
+}
+
+type openAPINode struct {
+	Name     string
+	Children []openAPINode
+	Parent   *openAPINode
+}
+
+func TestOpenAPIForRecursive(t *testing.T) {
+	data, err := OpenAPIFor(openAPINode{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	props := schema["properties"].(map[string]interface{})
+	node := map[string]interface{}{"type": "object"}
+	if !reflect.DeepEqual(props["parent"], node) {
+		t.Error("Unexpected schema of parent:", props["parent"])
+	}
+	if !reflect.DeepEqual(props["children"], map[string]interface{}{"type": "array", "items": node}) {
+		t.Error("Unexpected schema of children:", props["children"])
+	}
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	cases := map[string]string{
+		"/users/{id}":          "/users/{id}",
+		"/users/{id:[0-9]+}":   "/users/{id}",
+		"/users/:id/files/*fp": "/users/{id}/files/{fp}",
+		"/files/{path...}":     "/files/{path}",
+	}
+	for in, want := range cases {
+		if got, _ := openAPIPath(in); got != want {
+			t.Errorf("openAPIPath(%q) = %q, expected %q", in, got, want)
+		}
+	}
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	defer func(saved []Route) { routes = saved }(routes)
+	routes = nil
+	RegisterRoute("get", "/users/:user_id", openAPIQuery{})
+	RegisterRoute("POST", "/users", openAPIQuery{})
+
+	data, err := OpenAPISpec("Users", "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Parameters []struct {
+				Name     string
+				In       string
+				Required bool
+			}
+			RequestBody struct {
+				Content map[string]interface{}
+			} `json:"requestBody"`
+		}
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.OpenAPI != "3.1.0" {
+		t.Error("Unexpected version:", spec.OpenAPI)
+	}
+
+	get := spec.Paths["/users/{user_id}"]["get"]
+	if len(get.Parameters) != 5 {
+		t.Fatalf("Expected 5 parameters, got: %+v", get.Parameters)
+	}
+	if p := get.Parameters[0]; p.Name != "user_id" || p.In != "path" || !p.Required {
+		t.Errorf("Unexpected path parameter: %+v", p)
+	}
+	for _, p := range get.Parameters[1:] {
+		if p.In != "query" || p.Required != (p.Name == "order") {
+			t.Errorf("Unexpected query parameter: %+v", p)
+		}
+	}
+
+	post := spec.Paths["/users"]["post"]
+	if _, ok := post.RequestBody.Content["application/json"]; !ok {
+		t.Error("Expected a JSON request body:", post.RequestBody)
+	}
+}
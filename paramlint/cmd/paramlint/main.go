@@ -0,0 +1,68 @@
+// Command paramlint checks the parameter keys read by handlers, see the
+// paramlint package for the reports. It lives in its own module so that the
+// parameters module does not depend on golang.org/x/tools:
+//
+//	go install github.com/BakedSoftware/go-parameters/paramlint/cmd/paramlint@latest
+//
+//	paramlint ./...
+//	go vet -vettool=$(which paramlint) ./...
+//
+// With -catalog it prints the JSON catalog of the params accepted by each
+// function instead:
+//
+//	paramlint -catalog ./...
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/BakedSoftware/go-parameters/paramlint"
+	"golang.org/x/tools/go/analysis/singlechecker"
+	"golang.org/x/tools/go/packages"
+)
+
+// Package is the catalog of a package
+type Package struct {
+	Path      string                `json:"path"`
+	Functions []*paramlint.Function `json:"functions"`
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "-catalog" {
+		log.SetFlags(0)
+		log.SetPrefix("paramlint: ")
+		if err := catalog(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	singlechecker.Main(paramlint.Analyzer)
+}
+
+func catalog(patterns []string) error {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedImports | packages.NeedDeps |
+			packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return err
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return fmt.Errorf("could not load packages")
+	}
+
+	catalog := make([]Package, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		functions := paramlint.Collect(pkg.Fset, pkg.Syntax, pkg.TypesInfo)
+		if len(functions) > 0 {
+			catalog = append(catalog, Package{Path: pkg.PkgPath, Functions: functions})
+		}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(catalog)
+}
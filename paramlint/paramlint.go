@@ -0,0 +1,283 @@
+// Package paramlint defines an analyzer cataloguing the parameter keys read
+// by each function through *parameters.Params.
+//
+// The analyzer collects the constant keys given to the Get methods, HasAll
+// and Permit, grouped by top level function (closures count toward the
+// function declaring them), and reports:
+//
+//   - keys read but not permitted, when the function calls Permit
+//   - keys permitted but never read
+//   - keys read as different types, like GetInt("id") and GetString("id")
+//
+// Get itself reads a key without a type and never conflicts. Calls with keys
+// which are not constants are ignored, as is a Permit whose keys are not a
+// slice literal of constants.
+package paramlint
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+const paramsPath = "github.com/BakedSoftware/go-parameters"
+
+// Analyzer reports inconsistent parameter key use, its result is the
+// []*Function catalog of the package
+var Analyzer = &analysis.Analyzer{
+	Name:       "paramlint",
+	Doc:        "check parameter keys read, permitted and their types per function",
+	Run:        run,
+	ResultType: reflect.TypeOf([]*Function(nil)),
+}
+
+// Function lists the params accepted by a function
+type Function struct {
+	Name      string   `json:"name"`
+	Pos       string   `json:"pos"`
+	Params    []*Param `json:"params"`
+	Permitted []string `json:"permitted,omitempty"`
+
+	uses          []use
+	permitUnknown bool
+}
+
+// Param is a key read by a function. Types lists the Go types it is read
+// as, any for Get, and Required is set when it is checked by HasAll.
+type Param struct {
+	Key      string   `json:"key"`
+	Types    []string `json:"types"`
+	Required bool     `json:"required,omitempty"`
+}
+
+type useKind int
+
+const (
+	useRead useKind = iota
+	useHas
+	usePermit
+)
+
+type use struct {
+	key  string
+	typ  string
+	kind useKind
+	pos  token.Pos
+}
+
+// Collect returns the catalog of the functions of files reading params
+func Collect(fset *token.FileSet, files []*ast.File, info *types.Info) []*Function {
+	var functions []*Function
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.FuncDecl)
+			if !ok || decl.Body == nil {
+				continue
+			}
+			fn := &Function{Name: funcName(decl), Pos: fset.Position(decl.Pos()).String()}
+			ast.Inspect(decl.Body, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok {
+					fn.collect(call, info)
+				}
+				return true
+			})
+			if len(fn.uses) > 0 || fn.permitUnknown {
+				fn.build()
+				functions = append(functions, fn)
+			}
+		}
+	}
+	return functions
+}
+
+// funcName returns the name of a function as F, T.M or (*T).M
+func funcName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+	recv := decl.Recv.List[0].Type
+	if index, ok := recv.(*ast.IndexExpr); ok {
+		recv = index.X
+	}
+	if star, ok := recv.(*ast.StarExpr); ok {
+		return fmt.Sprintf("(*%s).%s", types.ExprString(star.X), decl.Name.Name)
+	}
+	return types.ExprString(recv) + "." + decl.Name.Name
+}
+
+// collect records the keys used by call if it is a method of Params
+func (fn *Function) collect(call *ast.CallExpr, info *types.Info) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return
+	}
+	selection, ok := info.Selections[sel]
+	if !ok || !isParams(selection.Recv()) {
+		return
+	}
+	method, ok := selection.Obj().(*types.Func)
+	if !ok {
+		return
+	}
+	name := method.Name()
+
+	switch {
+	case name == "Permit":
+		fn.collectPermit(call, info)
+	case name == "HasAll":
+		if call.Ellipsis.IsValid() {
+			return
+		}
+		for _, arg := range call.Args {
+			if key, ok := constantKey(arg, info); ok {
+				fn.uses = append(fn.uses, use{key: key, kind: useHas, pos: arg.Pos()})
+			}
+		}
+	case strings.HasPrefix(name, "Get") && len(call.Args) > 0:
+		key, ok := constantKey(call.Args[0], info)
+		if !ok {
+			return
+		}
+		typ := "any"
+		if name != "Get" {
+			sig := method.Type().(*types.Signature)
+			typ = types.TypeString(sig.Results().At(0).Type(), func(pkg *types.Package) string {
+				return pkg.Name()
+			})
+		}
+		fn.uses = append(fn.uses, use{key: key, typ: typ, kind: useRead, pos: call.Args[0].Pos()})
+	}
+}
+
+func (fn *Function) collectPermit(call *ast.CallExpr, info *types.Info) {
+	if len(call.Args) != 1 {
+		return
+	}
+	lit, ok := call.Args[0].(*ast.CompositeLit)
+	if !ok {
+		fn.permitUnknown = true
+		return
+	}
+	for _, elt := range lit.Elts {
+		key, ok := constantKey(elt, info)
+		if !ok {
+			fn.permitUnknown = true
+			continue
+		}
+		fn.uses = append(fn.uses, use{key: key, kind: usePermit, pos: elt.Pos()})
+	}
+}
+
+// isParams reports if t is parameters.Params or a pointer to it
+func isParams(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Name() == "Params" && obj.Pkg() != nil && obj.Pkg().Path() == paramsPath
+}
+
+// constantKey returns the value of expr if it is a constant string
+func constantKey(expr ast.Expr, info *types.Info) (string, bool) {
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// build fills the exported catalog fields from the uses
+func (fn *Function) build() {
+	params := make(map[string]*Param)
+	permitted := make(map[string]bool)
+	for _, u := range fn.uses {
+		if u.kind == usePermit {
+			if !permitted[u.key] {
+				permitted[u.key] = true
+				fn.Permitted = append(fn.Permitted, u.key)
+			}
+			continue
+		}
+		param, ok := params[u.key]
+		if !ok {
+			param = &Param{Key: u.key, Types: []string{}}
+			params[u.key] = param
+			fn.Params = append(fn.Params, param)
+		}
+		if u.kind == useHas {
+			param.Required = true
+		} else if !contains(param.Types, u.typ) {
+			param.Types = append(param.Types, u.typ)
+		}
+	}
+	for _, param := range fn.Params {
+		sort.Strings(param.Types)
+	}
+	sort.Slice(fn.Params, func(i, j int) bool { return fn.Params[i].Key < fn.Params[j].Key })
+	sort.Strings(fn.Permitted)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	functions := Collect(pass.Fset, pass.Files, pass.TypesInfo)
+	for _, fn := range functions {
+		check(pass, fn)
+	}
+	return functions, nil
+}
+
+func check(pass *analysis.Pass, fn *Function) {
+	permitted := make(map[string]bool)
+	read := make(map[string]bool)
+	hasPermit := fn.permitUnknown
+	for _, u := range fn.uses {
+		if u.kind == usePermit {
+			permitted[u.key] = true
+			hasPermit = true
+		} else {
+			read[u.key] = true
+		}
+	}
+
+	readAs := make(map[string]string)
+	for _, u := range fn.uses {
+		switch u.kind {
+		case usePermit:
+			if !read[u.key] {
+				pass.Reportf(u.pos, "key %q is permitted but never read by %s", u.key, fn.Name)
+			}
+			continue
+		case useRead:
+			if u.typ == "any" {
+				break
+			}
+			if prev, ok := readAs[u.key]; !ok {
+				readAs[u.key] = u.typ
+			} else if prev != u.typ {
+				pass.Reportf(u.pos, "key %q is read as %s and %s by %s", u.key, prev, u.typ, fn.Name)
+			}
+		}
+		if hasPermit && !fn.permitUnknown && !permitted[u.key] {
+			pass.Reportf(u.pos, "key %q is read but not permitted by %s", u.key, fn.Name)
+		}
+	}
+}
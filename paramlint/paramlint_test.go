@@ -0,0 +1,35 @@
+package paramlint
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	results := analysistest.Run(t, analysistest.TestData(), Analyzer, "handlers")
+	if len(results) != 1 {
+		t.Fatal("Expected a result, got:", len(results))
+	}
+
+	functions := results[0].Result.([]*Function)
+	if len(functions) != 3 {
+		t.Fatal("Expected 3 functions, got:", len(functions))
+	}
+	show := functions[0]
+	expected := []*Param{
+		{Key: "id", Types: []string{"uint64"}, Required: true},
+		{Key: "limit", Types: []string{"int"}},
+		{Key: "name", Types: []string{"string"}},
+	}
+	if show.Name != "Show" || !reflect.DeepEqual(show.Params, expected) {
+		t.Errorf("Unexpected catalog of %s: %+v", show.Name, show.Params)
+	}
+	if !reflect.DeepEqual(show.Permitted, []string{"id", "name", "unused"}) {
+		t.Error("Unexpected permitted keys:", show.Permitted)
+	}
+	if list := functions[1]; list.Name != "(*Server).List" || !reflect.DeepEqual(list.Params[0].Types, []string{"any", "int", "string"}) {
+		t.Errorf("Unexpected catalog of %s: %+v", list.Name, list.Params[0])
+	}
+}
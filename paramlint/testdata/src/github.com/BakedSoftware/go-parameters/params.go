@@ -0,0 +1,11 @@
+// Package parameters is a stub of the methods checked by paramlint
+package parameters
+
+type Params struct{}
+
+func (p *Params) Get(key string) (interface{}, bool)     { return nil, false }
+func (p *Params) GetInt(key string) int                  { return 0 }
+func (p *Params) GetUint64Ok(key string) (uint64, bool)  { return 0, false }
+func (p *Params) GetString(key string) string            { return "" }
+func (p *Params) HasAll(keys ...string) (bool, []string) { return true, nil }
+func (p *Params) Permit(allowedKeys []string)            {}
@@ -0,0 +1,28 @@
+package handlers
+
+import parameters "github.com/BakedSoftware/go-parameters"
+
+const keyName = "name"
+
+func Show(p *parameters.Params) {
+	p.Permit([]string{"id", keyName, "unused"}) // want `key "unused" is permitted but never read by Show`
+	p.HasAll("id")
+	p.GetUint64Ok("id")
+	p.GetString(keyName)
+	p.GetInt("limit") // want `key "limit" is read but not permitted by Show`
+}
+
+type Server struct{}
+
+func (s *Server) List(p *parameters.Params) {
+	func() {
+		p.GetInt("id")
+	}()
+	p.GetString("id") // want `key "id" is read as int and string by \(\*Server\).List`
+	p.Get("id")
+}
+
+func Dynamic(p *parameters.Params, keys []string) {
+	p.Permit(keys)
+	p.GetInt("id")
+}
@@ -8,13 +8,10 @@
 package parameters
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
-	"io"
-	"io/ioutil"
-	"log"
+	"log/slog"
 	"math"
 	"mime/multipart"
 	"net/http"
@@ -23,9 +20,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/gorilla/mux"
 	"github.com/julienschmidt/httprouter"
-	"github.com/ugorji/go/codec"
 )
 
 const (
@@ -33,8 +28,15 @@ const (
 )
 
 type Params struct {
-	isBinary bool
-	Values   map[string]interface{}
+	isBinary   bool
+	logger     *slog.Logger
+	sources    map[string]Source
+	precedence []Source
+	header     http.Header
+	cookies    []*http.Cookie
+	items      []*Params
+	bySource   map[Source]map[string]interface{}
+	Values     map[string]interface{}
 }
 
 func (p *Params) Get(key string) (interface{}, bool) {
@@ -60,7 +62,7 @@ func (p *Params) GetFloatOk(key string) (float64, bool) {
 		ok = err == nil
 	}
 	if ok {
-		return val.(float64), true
+		return toFloat64(val)
 	}
 	return 0, false
 }
@@ -89,12 +91,8 @@ func (p *Params) GetFloatSliceOk(key string) ([]float64, bool) {
 			raw := val.([]interface{})
 			slice := make([]float64, len(raw))
 			for i, k := range raw {
-				if num, ok := k.(float64); ok {
+				if num, ok := toFloat64(k); ok {
 					slice[i] = num
-				} else if num, ok := k.(string); ok {
-					if parsed, err := strconv.ParseFloat(num, 64); err == nil {
-						slice[i] = parsed
-					}
 				}
 			}
 			return slice, true
@@ -132,12 +130,17 @@ func (p *Params) GetBool(key string) bool {
 func (p *Params) GetIntOk(key string) (int, bool) {
 	val, ok := p.Get(key)
 	if sval, sok := val.(string); sok {
+		if ival, err := strconv.ParseInt(sval, 10, 64); err == nil {
+			return int(ival), true
+		}
 		var err error
 		val, err = strconv.ParseFloat(sval, 64)
 		ok = err == nil
 	}
 	if ok {
-		if ival, ok := val.(int64); ok {
+		if ival, ok := val.(int); ok {
+			return ival, true
+		} else if ival, ok := val.(int64); ok {
 			return int(ival), true
 		} else if fval, ok := val.(float64); ok {
 			return int(fval), true
@@ -248,6 +251,10 @@ func (p *Params) GetIntSliceOk(key string) ([]int, bool) {
 			for i, k := range raw {
 				if num, ok := k.(int); ok {
 					slice[i] = num
+				} else if num, ok := k.(int64); ok {
+					slice[i] = int(num)
+				} else if num, ok := k.(uint64); ok {
+					slice[i] = int(num)
 				} else if num, ok := k.(float64); ok {
 					slice[i] = int(num)
 				} else if num, ok := k.(string); ok {
@@ -277,7 +284,9 @@ func (p *Params) GetUint64Ok(key string) (uint64, bool) {
 		ok = err == nil && val.(float64) >= 0
 	}
 	if ok {
-		if valInt, ok := val.(int64); ok {
+		if valInt, ok := val.(int); ok && valInt >= 0 {
+			val = uint64(valInt)
+		} else if valInt, ok := val.(int64); ok {
 			val = uint64(valInt)
 		}
 		if valUint, ok := val.(uint64); ok {
@@ -377,7 +386,7 @@ func (p *Params) GetBytesOk(key string) ([]byte, bool) {
 			var err error
 			dataByte, err = base64.StdEncoding.DecodeString(dataStr.(string))
 			if err != nil {
-				log.Println("Error decoding data:", key, err)
+				p.log().Warn("parameters: invalid base64 value", "key", key, "error", err)
 				return nil, false
 			}
 			p.Values[key] = dataByte
@@ -418,23 +427,29 @@ func (p *Params) GetTimeInLocationOk(key string, loc *time.Location) (time.Time,
 		return t, true
 	}
 	if str, ok := val.(string); ok {
-		if t, err := time.ParseInLocation(time.RFC3339, str, loc); err == nil {
-			return t, true
-		}
-		if t, err := time.ParseInLocation(DateOnly, str, loc); err == nil {
-			return t, true
-		}
-		if t, err := time.ParseInLocation(DateTime, str, loc); err == nil {
-			return t, true
-		}
-		if t, err := time.ParseInLocation(HTMLDateTimeLocal, str, loc); err == nil {
-			return t, true
-		}
+		return parseTime(str, loc)
 	}
 
 	return time.Time{}, false
 }
 
+// parseTime parses the time formats accepted by GetTimeInLocationOk
+func parseTime(str string, loc *time.Location) (time.Time, bool) {
+	if t, err := time.ParseInLocation(time.RFC3339, str, loc); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation(DateOnly, str, loc); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation(DateTime, str, loc); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation(HTMLDateTimeLocal, str, loc); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
 func (p *Params) GetTimeInLocation(key string, loc *time.Location) time.Time {
 	t, _ := p.GetTimeInLocationOk(key, loc)
 	return t
@@ -474,34 +489,46 @@ func (p *Params) GetJSON(key string) map[string]interface{} {
 	return data
 }
 
+// MakeParsedReq parses the params of the request with DefaultParser before
+// calling fn, see GetParams. A request whose params cannot be parsed is
+// answered with a problem, see WriteProblem, without calling fn.
 func MakeParsedReq(fn http.HandlerFunc) http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
-		r = r.WithContext(context.WithValue(r.Context(), ParamsKey, ParseParams(r)))
-		fn(rw, r)
+		if r, ok := parseRequest(rw, r); ok {
+			fn(rw, r)
+		}
 	}
 }
 
+// ParsedHandler is the http.Handler flavor of MakeParsedReq, the wildcards of
+// the pattern matched by an http.ServeMux are path params
+func ParsedHandler(h http.Handler) http.Handler {
+	return MakeParsedReq(h.ServeHTTP)
+}
+
+// MakeHTTPRouterParsedReq parses the params like MakeParsedReq, the
+// httprouter params being stored in the request context for
+// HTTPRouterParams
 func MakeHTTPRouterParsedReq(fn httprouter.Handle) httprouter.Handle {
 	return func(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
-		r = r.WithContext(context.WithValue(r.Context(), ParamsKey, ParseParams(r)))
-		params := GetParams(r)
-		for _, param := range p {
-			const ID = "id"
-			if strings.Contains(param.Key, ID) {
-				id, perr := strconv.ParseUint(param.Value, 10, 64)
-				if perr != nil {
-					params.Values[param.Key] = param.Value
-				} else {
-					params.Values[param.Key] = id
-				}
-			} else {
-				params.Values[param.Key] = param.Value
-			}
+		r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, p))
+		if r, ok := parseRequest(rw, r); ok {
+			fn(rw, r, p)
 		}
-		fn(rw, r, p)
 	}
 }
 
+// parseRequest returns the request with its params in its context, or writes
+// the problem of the parse error
+func parseRequest(rw http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	params, err := DefaultParser.Parse(r)
+	if err != nil {
+		WriteProblem(rw, r, err)
+		return nil, false
+	}
+	return r.WithContext(context.WithValue(r.Context(), ParamsKey, params)), true
+}
+
 func GetParams(req *http.Request) *Params {
 	params := req.Context().Value(ParamsKey).(*Params)
 	return params
@@ -524,14 +551,53 @@ func (p *Params) Clone() *Params {
 	for k, v := range p.Values {
 		values[k] = v
 	}
-	return &Params{
-		isBinary: p.isBinary,
-		Values:   values,
+	sources := make(map[string]Source, len(p.sources))
+	for k, v := range p.sources {
+		sources[k] = v
 	}
+	bySource := make(map[Source]map[string]interface{}, len(p.bySource))
+	for source, vals := range p.bySource {
+		bySource[source] = vals
+	}
+	return &Params{
+		isBinary:   p.isBinary,
+		logger:     p.logger,
+		sources:    sources,
+		precedence: p.precedence,
+		header:     p.header,
+		cookies:    p.cookies,
+		items:      p.items,
+		bySource:   bySource,
+		Values:     values,
+	}
+}
+
+//Sets the parameters to the object by type; does not handle nested parameters.
+//Fields whose key is absent are set from their default tag if present:
+//
+// 	Limit int `default:"25"`
+//
+//Fields tagged with a header or cookie are only set from the request the
+//params were parsed from:
+//
+// 	DeviceID string `header:"X-Device-Id"`
+// 	Session  string `cookie:"session"`
+func (p *Params) Imbue(obj interface{}) {
+	p.imbue(obj)
 }
 
-//Sets the parameters to the object by type; does not handle nested parameters
-func (p *Params) Imbue(obj interface{}) {
+// invalidParam is a parameter which could not be converted to the type of
+// its field
+type invalidParam struct {
+	key   string
+	field reflect.StructField
+	value interface{}
+}
+
+// imbue sets the parameters to the object and returns the names of the
+// fields which were found or defaulted, and the parameters which could not be
+// converted
+func (p *Params) imbue(obj interface{}) (map[string]bool, []invalidParam) {
 
 	//Get the type of the object
 	typeOfObject := reflect.TypeOf(obj).Elem()
@@ -539,6 +605,11 @@ func (p *Params) Imbue(obj interface{}) {
 	//Get the object
 	objectValue := reflect.ValueOf(obj).Elem()
 
+	//Remember which fields were found to apply defaults to the others
+	found := make(map[string]bool, len(p.Values))
+
+	var invalid []invalidParam
+
 	//Loop our parameters
 	for k, _ := range p.Values {
 
@@ -546,82 +617,165 @@ func (p *Params) Imbue(obj interface{}) {
 		key := SnakeToCamelCase(k, true)
 
 		//Get the type and bool if found
-		fieldType, found := typeOfObject.FieldByName(key)
+		fieldType, ok := typeOfObject.FieldByName(key)
 
-		//Skip parameter if not found on struct
-		if !found {
+		//Skip parameter if not found on struct or bound to a header or cookie
+		if !ok {
+			continue
+		}
+		if _, _, bound := boundTag(fieldType); bound {
 			continue
 		}
 
-		//Get the field of the key
-		field := objectValue.FieldByName(key)
-
-		//Check our types and set accordingly
-		if fieldType.Type.Kind() == reflect.String {
-			//Set string
-			field.Set(reflect.ValueOf(p.GetString(k)))
-
-		} else if fieldType.Type.Kind() == reflect.Uint64 {
-			//Set Uint64
-			field.Set(reflect.ValueOf(p.GetUint64(k)))
-
-		} else if fieldType.Type.Kind() == reflect.Int {
-			//Set Int
-			field.Set(reflect.ValueOf(p.GetInt(k)))
-
-		} else if fieldType.Type.Kind() == reflect.Bool {
-			//Set bool
-			field.Set(reflect.ValueOf(p.GetBool(k)))
-
-		} else if fieldType.Type.Kind() == reflect.Float32 {
-			//Set float32
-			field.Set(reflect.ValueOf(float32(p.GetFloat(k))))
-
-		} else if fieldType.Type.Kind() == reflect.Float64 {
-			//Set float64
-			field.Set(reflect.ValueOf(p.GetFloat(k)))
-		} else if fieldType.Type == reflect.SliceOf(reflect.TypeOf("")) {
-			//Set []string
-			field.Set(reflect.ValueOf(p.GetStringSlice(k)))
-		} else if fieldType.Type == reflect.SliceOf(reflect.TypeOf(int(0))) {
-			//Set []int
-			field.Set(reflect.ValueOf(p.GetIntSlice(k)))
-		} else if fieldType.Type == reflect.SliceOf(reflect.TypeOf(uint64(0))) {
-			//Set []uint64
-			field.Set(reflect.ValueOf(p.GetUint64Slice(k)))
-		} else if fieldType.Type == reflect.SliceOf(reflect.TypeOf(float64(0))) {
-			//Set []float64
-			field.Set(reflect.ValueOf(p.GetFloatSlice(k)))
-		} else if fieldType.Type == typeOfTime {
-			//Set time.Time
-			field.Set(reflect.ValueOf(p.GetTime(k)))
-		} else if fieldType.Type == typeOfPtrToTime {
-			//Set *time.Time
-			t := p.GetTime(k)
-			field.Set(reflect.ValueOf(&t))
-		} else {
+		found[key] = true
+		if !p.setField(objectValue, key, fieldType.Type, k) {
 			val, _ := p.Get(k)
-			if CustomTypeSetter != nil && CustomTypeSetter(&field, val) == nil {
-				continue
-			}
+			invalid = append(invalid, invalidParam{key: k, field: fieldType, value: val})
+		}
+	}
 
-			if subVals, ok := p.GetJSONOk(k); ok {
-				fieldValue := reflect.Indirect(objectValue).FieldByName(key)
-				if reflect.ValueOf(fieldValue).IsZero() {
-					continue
-				}
+	p.imbueBound(typeOfObject, objectValue, found, &invalid)
+	imbueDefaults(typeOfObject, objectValue, found)
 
-				typeOfP := reflect.TypeOf(fieldValue.Interface())
-				newObj := reflect.New(typeOfP).Interface()
+	return found, invalid
+}
 
-				subParam := &Params{
-					Values: subVals,
-				}
-				subParam.Imbue(newObj)
-				field.Set(reflect.ValueOf(newObj).Elem())
+// imbueDefaults sets the fields which were not found in the params from their
+// default tag. The tag value is converted like a form value would be.
+func imbueDefaults(typeOfObject reflect.Type, objectValue reflect.Value, found map[string]bool) {
+	for i := 0; i < typeOfObject.NumField(); i++ {
+		fieldType := typeOfObject.Field(i)
+		if fieldType.Anonymous && fieldType.Type.Kind() == reflect.Struct {
+			imbueDefaults(fieldType.Type, objectValue.Field(i), found)
+			continue
+		}
+
+		def, ok := fieldType.Tag.Lookup("default")
+		if !ok || found[fieldType.Name] || fieldType.PkgPath != "" {
+			continue
+		}
+
+		k := CamelToSnakeCase(fieldType.Name)
+		defaults := &Params{Values: map[string]interface{}{k: formValue(def)}}
+		defaults.setField(objectValue, fieldType.Name, fieldType.Type, k)
+		found[fieldType.Name] = true
+	}
+}
+
+// setField sets the field named key of objectValue to the parameter k and
+// reports if the parameter could be converted to the type of the field
+func (p *Params) setField(objectValue reflect.Value, key string, fieldType reflect.Type, k string) bool {
+
+	//Get the field of the key
+	field := objectValue.FieldByName(key)
+
+	var ok bool
+
+	//Check our types and set accordingly
+	if fieldType.Kind() == reflect.String {
+		//Set string
+		var val string
+		val, ok = p.GetStringOk(k)
+		field.Set(reflect.ValueOf(strings.Trim(val, " ")))
+
+	} else if fieldType.Kind() == reflect.Uint64 {
+		//Set Uint64
+		var val uint64
+		val, ok = p.GetUint64Ok(k)
+		field.Set(reflect.ValueOf(val))
+
+	} else if fieldType.Kind() == reflect.Int {
+		//Set Int
+		var val int
+		val, ok = p.GetIntOk(k)
+		field.Set(reflect.ValueOf(val))
+
+	} else if fieldType.Kind() == reflect.Bool {
+		//Set bool
+		var val bool
+		val, ok = p.GetBoolOk(k)
+		field.Set(reflect.ValueOf(val))
+
+	} else if fieldType.Kind() == reflect.Float32 {
+		//Set float32
+		var val float64
+		val, ok = p.GetFloatOk(k)
+		field.Set(reflect.ValueOf(float32(val)))
+
+	} else if fieldType.Kind() == reflect.Float64 {
+		//Set float64
+		var val float64
+		val, ok = p.GetFloatOk(k)
+		field.Set(reflect.ValueOf(val))
+	} else if fieldType == reflect.SliceOf(reflect.TypeOf("")) {
+		//Set []string
+		var val []string
+		val, ok = p.GetStringSliceOk(k)
+		field.Set(reflect.ValueOf(val))
+	} else if fieldType == reflect.SliceOf(reflect.TypeOf(int(0))) {
+		//Set []int
+		var val []int
+		val, ok = p.GetIntSliceOk(k)
+		field.Set(reflect.ValueOf(val))
+	} else if fieldType == reflect.SliceOf(reflect.TypeOf(uint64(0))) {
+		//Set []uint64
+		var val []uint64
+		val, ok = p.GetUint64SliceOk(k)
+		field.Set(reflect.ValueOf(val))
+	} else if fieldType == reflect.SliceOf(reflect.TypeOf(float64(0))) {
+		//Set []float64
+		var val []float64
+		val, ok = p.GetFloatSliceOk(k)
+		field.Set(reflect.ValueOf(val))
+	} else if fieldType == typeOfTime {
+		//Set time.Time
+		var val time.Time
+		val, ok = p.GetTimeOk(k)
+		field.Set(reflect.ValueOf(val))
+	} else if fieldType == typeOfPtrToTime {
+		//Set *time.Time
+		var val time.Time
+		val, ok = p.GetTimeOk(k)
+		field.Set(reflect.ValueOf(&val))
+	} else {
+		val, _ := p.Get(k)
+		if CustomTypeSetter != nil && CustomTypeSetter(&field, val) == nil {
+			return true
+		}
+
+		if subVals, ok := p.GetJSONOk(k); ok {
+			fieldValue := reflect.Indirect(objectValue).FieldByName(key)
+			if reflect.ValueOf(fieldValue).IsZero() {
+				return true
 			}
+
+			typeOfP := reflect.TypeOf(fieldValue.Interface())
+			newObj := reflect.New(typeOfP).Interface()
+
+			subParam := &Params{
+				Values: subVals,
+			}
+			subParam.Imbue(newObj)
+			field.Set(reflect.ValueOf(newObj).Elem())
+			return true
 		}
 	}
+
+	return ok
+}
+
+// WithDefaults sets the given values for the keys which are not present and
+// returns the params
+func (p *Params) WithDefaults(defaults map[string]interface{}) *Params {
+	if p.Values == nil {
+		p.Values = make(map[string]interface{}, len(defaults))
+	}
+	for k, v := range defaults {
+		if _, pres := p.Values[k]; !pres {
+			p.Values[k] = v
+		}
+	}
+	return p
 }
 
 // HasAll will return if all specified keys are found in the params object
@@ -654,105 +808,30 @@ func contains(haystack []string, needle string) bool {
 	return false
 }
 
+// ParseParams parses the params of a request with DefaultParser, the parse
+// errors are only logged
 func ParseParams(req *http.Request) *Params {
-	var p Params
-	if params, exists := req.Context().Value(ParamsKey).(*Params); exists {
-		return params
-	}
-	ct := req.Header.Get("Content-Type")
-	ct = strings.Split(ct, ";")[0]
-	if ct == "multipart/form-data" {
-		if err := req.ParseMultipartForm(10000000); err != nil {
-			log.Println("Request.ParseMultipartForm Error", err)
-		}
-	} else {
-		if err := req.ParseForm(); err != nil {
-			log.Println("Request.ParseForm Error", err)
-		}
-	}
-	tmap := make(map[string]interface{}, len(req.Form))
-	for k, v := range req.Form {
-		if strings.ToLower(v[0]) == "true" {
-			tmap[k] = true
-		} else if strings.ToLower(v[0]) == "false" {
-			tmap[k] = false
-		} else {
-			tmap[k] = v[0]
-		}
-	}
+	params, _ := DefaultParser.Parse(req)
+	return params
+}
 
-	if req.MultipartForm != nil {
-		for k, v := range req.MultipartForm.File {
-			tmap[k] = v[0]
-		}
-	}
-
-	if ct == "application/json" && req.ContentLength > 0 {
-		err := json.NewDecoder(req.Body).Decode(&p.Values)
-		if err != nil {
-			log.Println("Content-Type is \"application/json\" but no valid json data received", err)
-			p.Values = tmap
-		}
-		for k, v := range tmap {
-			if _, pres := p.Values[k]; !pres {
-				p.Values[k] = v
-			}
-		}
-	} else if ct == "application/x-msgpack" {
-		var mh codec.MsgpackHandle
-		p.isBinary = true
-		mh.MapType = reflect.TypeOf(p.Values)
-		body, _ := ioutil.ReadAll(req.Body)
-		if len(body) > 0 {
-			buff := bytes.NewBuffer(body)
-			first := body[0]
-			if (first >= 0x80 && first <= 0x8f) || (first == 0xde || first == 0xdf) {
-				err := codec.NewDecoder(buff, &mh).Decode(&p.Values)
-				if err != nil && err != io.EOF {
-					log.Println("Failed decoding msgpack", err)
-				}
-			} else {
-				if p.Values == nil {
-					p.Values = make(map[string]interface{}, 0)
-				}
-				var err error
-				for err == nil {
-					vals := make([]interface{}, 0)
-					err = codec.NewDecoder(buff, &mh).Decode(&vals)
-					if err != nil && err != io.EOF {
-						log.Println("Failed decoding msgpack", err)
-					} else {
-						for i := len(vals) - 1; i >= 1; i -= 2 {
-							p.Values[string(vals[i-1].([]byte))] = vals[i]
-						}
-					}
-				}
-			}
-		} else {
-			p.Values = make(map[string]interface{}, 0)
-		}
-		for k, v := range tmap {
-			if _, pres := p.Values[k]; !pres {
-				p.Values[k] = v
-			}
-		}
-	} else {
-		p.Values = tmap
+// formValue converts the boolean literals of a form value
+func formValue(v string) interface{} {
+	if strings.ToLower(v) == "true" {
+		return true
+	} else if strings.ToLower(v) == "false" {
+		return false
 	}
+	return v
+}
 
-	for k, v := range mux.Vars(req) {
-		const ID = "id"
-		if strings.Contains(k, ID) {
-			id, perr := strconv.ParseUint(v, 10, 64)
-			if perr != nil {
-				p.Values[k] = v
-			} else {
-				p.Values[k] = id
-			}
-		} else {
-			p.Values[k] = v
+// formValues converts the first value of each form key with formValue
+func formValues(form map[string][]string) map[string]interface{} {
+	values := make(map[string]interface{}, len(form))
+	for k, v := range form {
+		if len(v) > 0 {
+			values[k] = formValue(v[0])
 		}
 	}
-
-	return &p
+	return values
 }
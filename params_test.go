@@ -3,6 +3,7 @@ package parameters
 import (
 	"context"
 	"errors"
+	"io"
 	"net/http"
 	"reflect"
 	"strings"
@@ -215,6 +216,7 @@ func TestParseJSONBodyMux(t *testing.T) {
 	m := mux.NewRouter()
 	m.KeepContext = true
 	m.HandleFunc("/test/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		r = WithCoercions(r, Coercions{"id": CoerceUint64})
 		r = r.WithContext(context.WithValue(r.Context(), ParamsKey, ParseParams(r)))
 
 		params := GetParams(r)
@@ -503,3 +505,99 @@ func TestCustomTypeSetter(t *testing.T) {
 	}
 
 }
+
+func TestImbueDefaults(t *testing.T) {
+	body := "limit=10"
+	r, err := http.NewRequest("PUT", "test", strings.NewReader(body))
+	if err != nil {
+		t.Fatal("Could not build request", err)
+	}
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	params := ParseParams(r)
+
+	type testType struct {
+		Limit   int       `default:"25"`
+		Offset  int       `default:"5"`
+		Active  bool      `default:"true"`
+		Order   string    `default:"desc"`
+		IDs     []uint64  `default:"1,2,3"`
+		Since   time.Time `default:"2016-07-17"`
+		Missing string
+	}
+
+	var obj testType
+	params.Imbue(&obj)
+
+	if obj.Limit != 10 {
+		t.Error("Present 'limit' should not be defaulted, got:", obj.Limit)
+	}
+	if obj.Offset != 5 {
+		t.Error("Value of 'offset' should be 5, got:", obj.Offset)
+	}
+	if !obj.Active {
+		t.Error("Value of 'active' should be 'true', got:", obj.Active)
+	}
+	if obj.Order != "desc" {
+		t.Error("Value of 'order' should be 'desc', got:", obj.Order)
+	}
+	if !reflect.DeepEqual(obj.IDs, []uint64{1, 2, 3}) {
+		t.Error("Value of 'ids' should be [1 2 3], got:", obj.IDs)
+	}
+	since, _ := time.Parse(DateOnly, "2016-07-17")
+	if !obj.Since.Equal(since) {
+		t.Error("Value of 'since' should be 2016-07-17, got:", obj.Since)
+	}
+	if obj.Missing != "" {
+		t.Error("Value of 'missing' should be empty, got:", obj.Missing)
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	params := &Params{Values: map[string]interface{}{"limit": 10.0}}
+	params.WithDefaults(map[string]interface{}{"limit": 25, "page": 2, "order": "asc"})
+
+	if limit := params.GetInt("limit"); limit != 10 {
+		t.Error("Present 'limit' should not be overridden, got:", limit)
+	}
+	if page := params.GetInt("page"); page != 2 {
+		t.Error("Value of 'page' should be 2, got:", page)
+	}
+	if order := params.GetString("order"); order != "asc" {
+		t.Error("Value of 'order' should be 'asc', got:", order)
+	}
+
+	empty := (&Params{}).WithDefaults(map[string]interface{}{"order": "asc"})
+	if order := empty.GetString("order"); order != "asc" {
+		t.Error("Value of 'order' should be 'asc', got:", order)
+	}
+
+	ints := (&Params{}).WithDefaults(map[string]interface{}{
+		"ratio":   1,
+		"scale":   int64(2),
+		"weights": []interface{}{1, int64(2), uint64(3), 4.5},
+	})
+	if ratio := ints.GetFloat("ratio"); ratio != 1 {
+		t.Error("Value of 'ratio' should be 1, got:", ratio)
+	}
+	if scale, ok := ints.GetFloatOk("scale"); !ok || scale != 2 {
+		t.Error("Value of 'scale' should be 2, got:", scale)
+	}
+	if weights := ints.GetFloatSlice("weights"); !reflect.DeepEqual(weights, []float64{1, 2, 3, 4.5}) {
+		t.Error("Unexpected 'weights':", weights)
+	}
+}
+
+// buildRequest builds a request with http.NewRequest and adds the headers
+func buildRequest(t *testing.T, method, url string, body io.Reader, header http.Header) *http.Request {
+	r, err := http.NewRequest(method, url, body)
+	if err != nil {
+		t.Fatal("Could not build request", err)
+	}
+	for k, values := range header {
+		for _, v := range values {
+			r.Header.Add(k, v)
+		}
+	}
+	return r
+}
@@ -0,0 +1,289 @@
+package parameters
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+var packageLogger atomic.Pointer[slog.Logger]
+
+// SetLogger sets the logger used by the package when a parser has none, by
+// default slog.Default()
+func SetLogger(logger *slog.Logger) {
+	packageLogger.Store(logger)
+}
+
+func defaultLogger() *slog.Logger {
+	if logger := packageLogger.Load(); logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// DefaultMaxDecompressedSize is the size limit of compressed request bodies
+// once decompressed when the parser sets none
+const DefaultMaxDecompressedSize = 32 << 20
+
+// DefaultMaxRecordSize is the size limit of the records of ForEach when the
+// parser sets none
+const DefaultMaxRecordSize = 1 << 20
+
+// ErrTooManyKeys is returned by Parse and ForEach when params exceed the
+// MaxKeys of the parser
+var ErrTooManyKeys = errors.New("parameters: too many keys")
+
+// ErrBodyTooLarge is returned by Parse and ForEach when a compressed request
+// body exceeds MaxDecompressedSize once decompressed, and by ForEach when a
+// record exceeds MaxRecordSize
+var ErrBodyTooLarge = errors.New("parameters: request body is too large")
+
+// Parser parses the params of requests, ParseParams uses DefaultParser
+type Parser struct {
+	// Logger receives the diagnostics of the parser and of the params it
+	// parses, the package logger is used when nil
+	Logger *slog.Logger
+
+	// MaxDecompressedSize limits the size of the request bodies sent with a
+	// gzip or deflate Content-Encoding once decompressed,
+	// DefaultMaxDecompressedSize when 0
+	MaxDecompressedSize int64
+
+	// MaxRecordSize limits the size of each record of ForEach,
+	// DefaultMaxRecordSize when 0
+	MaxRecordSize int64
+
+	// MaxKeys limits the number of keys of the params, counted at any depth,
+	// and of each record of ForEach. The params of a request exceeding it
+	// are empty. There is no limit when 0.
+	MaxKeys int
+
+	// PathParams lists the routers the path params are taken from,
+	// DefaultPathParamSources when nil
+	PathParams []PathParamSource
+
+	// Coercions converts the path params of every route, the coercions of a
+	// route taking precedence, see WithCoercions
+	Coercions Coercions
+
+	// IDHeuristic parses the path params whose name contains "id" as uint64
+	// when they have no coercion, as earlier versions did
+	IDHeuristic bool
+
+	// Headers maps the names of the headers to parse to their key in the
+	// params, the first value of a header being used
+	Headers map[string]string
+
+	// Cookies maps the names of the cookies to parse to their key in the
+	// params
+	Cookies map[string]string
+
+	// ItemsKey is the key of the items of a top level JSON array body,
+	// DefaultItemsKey when empty, see Params.Items
+	ItemsKey string
+
+	// Precedence lists the sources from the highest precedence when a key
+	// is sent in several of them, DefaultPrecedence when nil. The sources
+	// left out are not set in Values but remain available through
+	// Params.From.
+	Precedence []Source
+
+	// Strict makes Parse return a ConflictError when a key is sent in two
+	// sources of the precedence list with different values
+	Strict bool
+}
+
+// DefaultParser is the parser used by ParseParams and the Make*ParsedReq
+// wrappers
+var DefaultParser = &Parser{}
+
+func (ps *Parser) logger() *slog.Logger {
+	if ps.Logger != nil {
+		return ps.Logger
+	}
+	return defaultLogger()
+}
+
+// Parse parses the form, multipart, JSON or msgpack body of a request and
+// its path params. Bodies sent with a gzip or deflate Content-Encoding are
+// decompressed first, up to MaxDecompressedSize. The params are returned
+// even when part of the request could not be parsed, along with the first
+// error, which is logged. Params already in the context of the request are
+// returned as is.
+//
+// A key sent in several sources takes the value of the source of highest
+// Precedence, by default the path params, then the JSON or msgpack body, the
+// files, the form body, the query string, the headers and the cookies, see
+// Params.Source.
+func (ps *Parser) Parse(req *http.Request) (*Params, error) {
+	if params, exists := req.Context().Value(ParamsKey).(*Params); exists {
+		return params, nil
+	}
+	p := Params{logger: ps.Logger}
+	if ps.Precedence != nil {
+		p.precedence = expandSources(ps.Precedence)
+	}
+	logger := ps.logger()
+
+	var firstErr error
+	fail := func(msg string, err error) {
+		logger.Warn(msg, "method", req.Method, "path", req.URL.Path, "error", err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if err := ps.decompress(req); err != nil {
+		fail("parameters: could not decompress body", err)
+	}
+
+	ct := req.Header.Get("Content-Type")
+	ct = strings.Split(ct, ";")[0]
+	if ct == "multipart/form-data" {
+		if err := req.ParseMultipartForm(10000000); err != nil {
+			fail("parameters: could not parse multipart form", err)
+		}
+	} else {
+		if err := req.ParseForm(); err != nil {
+			fail("parameters: could not parse form", err)
+		}
+	}
+	p.header, p.cookies = req.Header, req.Cookies()
+	p.setSource(SourceHeader, ps.headerValues(req))
+	p.setSource(SourceCookie, ps.cookieValues(req))
+	p.setSource(SourceQuery, formValues(req.URL.Query()))
+	p.setSource(SourceForm, formValues(req.PostForm))
+
+	if req.MultipartForm != nil {
+		files := make(map[string]interface{}, len(req.MultipartForm.File))
+		for k, v := range req.MultipartForm.File {
+			files[k] = v[0]
+		}
+		p.setSource(SourceFile, files)
+	}
+
+	if ct == "application/json" && req.ContentLength > 0 {
+		if body, err := ps.decodeJSON(&p, req.Body); err != nil {
+			fail("parameters: invalid JSON body, falling back to form values", err)
+		} else {
+			p.setSource(SourceJSON, body)
+		}
+	} else if ct == "application/x-msgpack" {
+		p.isBinary = true
+		data, _ := ioutil.ReadAll(req.Body)
+		if body, err := decodeMsgpack(data); err != nil {
+			fail("parameters: invalid msgpack body, falling back to form values", err)
+		} else {
+			p.setSource(SourceMsgpack, body)
+		}
+	}
+
+	if err := ps.setPathParams(&p, req); err != nil {
+		fail("parameters: invalid path param", err)
+	}
+	if err := p.merge(ps.Strict); err != nil {
+		fail("parameters: conflicting params", err)
+	}
+	if err := ps.checkKeys(p.Values); err != nil {
+		fail("parameters: too many keys", err)
+		p.Values, p.sources, p.bySource, p.items = make(map[string]interface{}), nil, nil, nil
+	}
+
+	return &p, firstErr
+}
+
+// decompress replaces a compressed request body by its content, the body is
+// dropped when it cannot be decompressed
+func (ps *Parser) decompress(req *http.Request) error {
+	encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body := req.Body
+	defer body.Close()
+	req.Body, req.ContentLength = http.NoBody, 0
+	req.Header.Del("Content-Encoding")
+
+	r, err := decompressReader(encoding, body)
+	if err != nil {
+		return err
+	}
+
+	max := ps.maxDecompressedSize()
+	data, err := ioutil.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) > max {
+		return ErrBodyTooLarge
+	}
+	req.Body, req.ContentLength = ioutil.NopCloser(bytes.NewReader(data)), int64(len(data))
+	return nil
+}
+
+func (ps *Parser) maxDecompressedSize() int64 {
+	if ps.MaxDecompressedSize > 0 {
+		return ps.MaxDecompressedSize
+	}
+	return DefaultMaxDecompressedSize
+}
+
+// decompressReader returns a reader of the content of a body sent with a
+// gzip or deflate Content-Encoding
+func decompressReader(encoding string, body io.Reader) (io.Reader, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(body)
+	case "deflate":
+		// deflate is zlib wrapped, but some clients send raw deflate
+		br := bufio.NewReader(body)
+		if header, _ := br.Peek(2); len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	}
+	return nil, fmt.Errorf("parameters: unsupported Content-Encoding %q", encoding)
+}
+
+// log returns the logger of the parser which parsed the params
+func (p *Params) log() *slog.Logger {
+	if p.logger != nil {
+		return p.logger
+	}
+	return defaultLogger()
+}
+
+// LogValue logs the params as a group redacted by DefaultRedactor, so they
+// can be logged safely:
+//
+//	logger.Info("request", "params", params)
+func (p *Params) LogValue() slog.Value {
+	if p == nil {
+		return slog.GroupValue()
+	}
+	return logGroup(filterMap(p).Values)
+}
+
+func logGroup(values map[string]interface{}) slog.Value {
+	attrs := make([]slog.Attr, 0, len(values))
+	for k, v := range values {
+		if nested, ok := v.(map[string]interface{}); ok {
+			attrs = append(attrs, slog.Attr{Key: k, Value: logGroup(nested)})
+		} else {
+			attrs = append(attrs, slog.Any(k, v))
+		}
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+	return slog.GroupValue(attrs...)
+}
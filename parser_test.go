@@ -0,0 +1,123 @@
+package parameters
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParserLogsParseFailures(t *testing.T) {
+	var buf bytes.Buffer
+	parser := &Parser{Logger: slog.New(slog.NewTextHandler(&buf, nil))}
+
+	r := httptest.NewRequest("POST", "/users?name=bob", strings.NewReader("{invalid"))
+	r.Header.Set("Content-Type", "application/json")
+	params, err := parser.Parse(r)
+	if err == nil {
+		t.Error("Expected an error for the invalid JSON body")
+	}
+	if name := params.GetString("name"); name != "bob" {
+		t.Error("Expected the form values as fallback, got:", params.Values)
+	}
+	if line := buf.String(); !strings.Contains(line, "level=WARN") || !strings.Contains(line, "path=/users") {
+		t.Error("Unexpected log:", line)
+	}
+
+	buf.Reset()
+	params.Values["data"] = "not base64!"
+	if _, ok := params.GetBytesOk("data"); ok {
+		t.Error("Expected invalid base64 to fail")
+	}
+	if line := buf.String(); !strings.Contains(line, "key=data") {
+		t.Error("Expected the base64 error to be logged by the parser logger:", line)
+	}
+}
+
+func TestParamsLogValue(t *testing.T) {
+	defer func(keys []string) { FilteredKeys = keys }(FilteredKeys)
+	FilteredKeys = []string{"password"}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	params := &Params{Values: map[string]interface{}{
+		"name":     "bob",
+		"password": "secret",
+		"user":     map[string]interface{}{"password": "secret", "age": 30},
+	}}
+	logger.Info("req", "params", params)
+
+	line := buf.String()
+	for _, part := range []string{"params.name=bob", "params.password=FILTERED", "params.user.age=30", "params.user.password=FILTERED"} {
+		if !strings.Contains(line, part) {
+			t.Errorf("Expected %q in log: %s", part, line)
+		}
+	}
+	if strings.Contains(line, "secret") {
+		t.Error("Filtered value was logged:", line)
+	}
+}
+
+// compressBody compresses body with encoding, raw deflate being sent as
+// deflate, and returns it with its Content-Encoding
+func compressBody(t *testing.T, encoding string, body string) (io.Reader, string) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	default:
+		fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, encoding = fw, "deflate"
+	}
+	io.WriteString(w, body)
+	w.Close()
+	return &buf, encoding
+}
+
+func TestParserDecompressesBody(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate", "raw"} {
+		body, contentEncoding := compressBody(t, encoding, `{"name": "bob", "count": 3}`)
+		params, err := DefaultParser.Parse(buildRequest(t, "POST", "/upload?source=batch", body, http.Header{
+			"Content-Type":     {"application/json"},
+			"Content-Encoding": {contentEncoding},
+		}))
+		if err != nil {
+			t.Fatalf("%s: %v", encoding, err)
+		}
+		if params.GetString("name") != "bob" || params.GetInt("count") != 3 || params.GetString("source") != "batch" {
+			t.Errorf("%s: unexpected params %v", encoding, params.Values)
+		}
+	}
+}
+
+func TestParserMaxDecompressedSize(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer SetLogger(nil)
+
+	parser := &Parser{MaxDecompressedSize: 1024}
+	body, _ := compressBody(t, "gzip", `{"data": "`+strings.Repeat("a", 4096)+`"}`)
+	params, err := parser.Parse(buildRequest(t, "POST", "/upload?source=batch", body, http.Header{
+		"Content-Type":     {"application/json"},
+		"Content-Encoding": {"gzip"},
+	}))
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Error("Expected ErrBodyTooLarge, got:", err)
+	}
+	if _, ok := params.Get("data"); ok || params.GetString("source") != "batch" {
+		t.Error("Expected only the query params, got:", params.Values)
+	}
+}
@@ -0,0 +1,99 @@
+package parameters
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/julienschmidt/httprouter"
+)
+
+// PathParamSource returns the params matched in the path of a request by a
+// router
+type PathParamSource interface {
+	PathParams(req *http.Request) map[string]string
+}
+
+// PathParamSourceFunc adapts a function to a PathParamSource
+type PathParamSourceFunc func(req *http.Request) map[string]string
+
+// PathParams calls f(req)
+func (f PathParamSourceFunc) PathParams(req *http.Request) map[string]string {
+	return f(req)
+}
+
+// Path param sources of the supported routers
+var (
+	// MuxVars returns the gorilla/mux vars
+	MuxVars PathParamSource = PathParamSourceFunc(mux.Vars)
+
+	// HTTPRouterParams returns the httprouter params stored in the request
+	// context, by MakeHTTPRouterParsedReq or the http.Handler adapters of
+	// httprouter.Router
+	HTTPRouterParams PathParamSource = PathParamSourceFunc(httprouterParams)
+
+	// ServeMuxValues returns the wildcards of the pattern matched by an
+	// http.ServeMux
+	ServeMuxValues PathParamSource = PathParamSourceFunc(serveMuxValues)
+)
+
+// DefaultPathParamSources is used by the parsers without PathParams
+var DefaultPathParamSources = []PathParamSource{MuxVars, HTTPRouterParams, ServeMuxValues}
+
+func httprouterParams(req *http.Request) map[string]string {
+	params := httprouter.ParamsFromContext(req.Context())
+	if len(params) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(params))
+	for _, param := range params {
+		values[param.Key] = param.Value
+	}
+	return values
+}
+
+var serveMuxWildcardRe = regexp.MustCompile(`\{([^}.$]+)(?:\.\.\.)?\}`)
+
+func serveMuxValues(req *http.Request) map[string]string {
+	if req.Pattern == "" {
+		return nil
+	}
+	matches := serveMuxWildcardRe.FindAllStringSubmatch(req.Pattern, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(matches))
+	for _, match := range matches {
+		values[match[1]] = req.PathValue(match[1])
+	}
+	return values
+}
+
+// setPathParams sets the path params of the sources of the parser, it
+// returns the first coercion error by key
+func (ps *Parser) setPathParams(p *Params, req *http.Request) error {
+	sources := ps.PathParams
+	if sources == nil {
+		sources = DefaultPathParamSources
+	}
+	var firstErr error
+	values := make(map[string]interface{})
+	for _, source := range sources {
+		params := source.PathParams(req)
+		keys := make([]string, 0, len(params))
+		for k := range params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			value, err := ps.coerce(req, k, params[k])
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			values[k] = value
+		}
+	}
+	p.setSource(SourcePath, values)
+	return firstErr
+}
@@ -0,0 +1,59 @@
+package parameters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestServeMuxValues(t *testing.T) {
+	var params *Params
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{user_id}/files/{path...}", Coercions{"user_id": CoerceUint64}.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params = ParseParams(r)
+	})))
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42/files/a/b.txt?q=1", nil))
+
+	if params == nil {
+		t.Fatal("ServeMux did not match")
+	}
+	if id, ok := params.Values["user_id"].(uint64); !ok || id != 42 {
+		t.Error("Expected user_id to be uint64(42), got:", params.Values["user_id"])
+	}
+	if path := params.GetString("path"); path != "a/b.txt" {
+		t.Error("Unexpected path:", path)
+	}
+}
+
+func TestHTTPRouterParams(t *testing.T) {
+	var params *Params
+	router := httprouter.New()
+	router.GET("/users/:user_id", Coercions{"user_id": CoerceUint64}.Handle(MakeHTTPRouterParsedReq(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		params = GetParams(r)
+	})))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+	if params == nil {
+		t.Fatal("httprouter did not match")
+	}
+	if id, ok := params.Values["user_id"].(uint64); !ok || id != 42 {
+		t.Error("Expected user_id to be uint64(42), got:", params.Values["user_id"])
+	}
+}
+
+func TestParserPathParamSources(t *testing.T) {
+	source := PathParamSourceFunc(func(req *http.Request) map[string]string {
+		return map[string]string{"slug": "hello", "account_id": "7"}
+	})
+	parser := &Parser{PathParams: []PathParamSource{source}, IDHeuristic: true}
+
+	params, err := parser.Parse(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.GetString("slug") != "hello" || params.Values["account_id"] != uint64(7) {
+		t.Error("Unexpected params:", params.Values)
+	}
+}
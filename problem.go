@@ -0,0 +1,218 @@
+package parameters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	// BoundKey is the context key of the struct bound by MakeValidatedReq
+	BoundKey = "bound"
+)
+
+// Problem is an RFC 7807 problem details object. Errors lists the parameters
+// which failed binding or validation.
+type Problem struct {
+	Type     string         `json:"type,omitempty"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Errors   []ProblemError `json:"errors,omitempty"`
+}
+
+// ProblemError describes a single invalid parameter of a Problem. Field is
+// the parameter name or a JSON pointer for schema violations, Code is the
+// failed rule.
+type ProblemError struct {
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Param  string `json:"param,omitempty"`
+	Detail string `json:"detail"`
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Title + ": " + p.Detail
+	}
+	return p.Title
+}
+
+// ParamsValidator is implemented by the schemas which validate params, like
+// *ObjectSchema and *JSONSchema
+type ParamsValidator interface {
+	ValidateParams(p *Params) error
+}
+
+// NewProblem converts an error to a problem: validation and type errors are
+// 422 Unprocessable Entity with an entry per parameter, ErrBodyTooLarge is
+// 413 Request Entity Too Large, a *Problem is returned as is and other errors
+// are 400 Bad Request
+func NewProblem(err error) *Problem {
+	return newProblem(err, "")
+}
+
+// newProblem converts an error to a problem with messages rendered in locale
+func newProblem(err error, locale string) *Problem {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		return problem
+	}
+
+	var tagErr *TagError
+	if errors.As(err, &tagErr) {
+		return &Problem{
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Status: http.StatusInternalServerError,
+		}
+	}
+
+	if errors.Is(err, ErrBodyTooLarge) {
+		return &Problem{
+			Title:  http.StatusText(http.StatusRequestEntityTooLarge),
+			Status: http.StatusRequestEntityTooLarge,
+			Detail: err.Error(),
+		}
+	}
+
+	var fieldErrs ValidationErrors
+	var fieldErr *FieldError
+	var typeErr *TypeError
+	switch {
+	case errors.As(err, &fieldErrs):
+	case errors.As(err, &fieldErr):
+		fieldErrs = ValidationErrors{fieldErr}
+	case errors.As(err, &typeErr):
+		fieldErrs = ValidationErrors{{Field: typeErr.Key, Tag: "type", Param: typeErr.Type}}
+	default:
+		return &Problem{
+			Title:  http.StatusText(http.StatusBadRequest),
+			Status: http.StatusBadRequest,
+			Detail: err.Error(),
+		}
+	}
+
+	problem = &Problem{
+		Title:  http.StatusText(http.StatusUnprocessableEntity),
+		Status: http.StatusUnprocessableEntity,
+		Detail: "One or more parameters are invalid",
+		Errors: make([]ProblemError, len(fieldErrs)),
+	}
+	if msg, ok := Messages.lookup(locale, "problem.invalid"); ok {
+		problem.Detail = msg
+	}
+	for i, e := range fieldErrs {
+		problem.Errors[i] = ProblemError{
+			Field:  e.Field,
+			Code:   e.Tag,
+			Param:  e.Param,
+			Detail: Messages.Localize(e, locale),
+		}
+	}
+	return problem
+}
+
+// WriteProblem writes err as an application/problem+json response, or as
+// msgpack when the request was sent in msgpack. The messages are rendered
+// from Messages in the locale negotiated with the Accept-Language header.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	locale := Messages.Locale(r.Header.Get("Accept-Language"))
+	problem := *newProblem(err, locale)
+	if problem.Status == 0 {
+		problem.Status = http.StatusInternalServerError
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	w.Header().Set("Content-Language", locale)
+	if problem.Instance == "" && r.URL != nil {
+		problem.Instance = r.URL.Path
+	}
+
+	if isBinaryRequest(r) {
+		var mh codec.MsgpackHandle
+		var body []byte
+		if err := codec.NewEncoderBytes(&body, &mh).Encode(problem); err == nil {
+			w.Header().Set("Content-Type", "application/x-msgpack")
+			w.WriteHeader(problem.Status)
+			w.Write(body)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// isBinaryRequest reports if the request was sent or asks to be answered in
+// msgpack
+func isBinaryRequest(r *http.Request) bool {
+	if params, ok := r.Context().Value(ParamsKey).(*Params); ok && params.isBinary {
+		return true
+	}
+	ct := strings.Split(r.Header.Get("Content-Type"), ";")[0]
+	return ct == "application/x-msgpack" || strings.Contains(r.Header.Get("Accept"), "application/x-msgpack")
+}
+
+// MakeValidatedReq parses the params like MakeParsedReq and validates them
+// before calling fn. A request which cannot be parsed is answered with a
+// problem, path params which cannot be coerced being reported like invalid
+// params. schemaOrStruct is either a ParamsValidator or a struct
+// (or pointer to one) whose validate tags are checked by binding a new
+// instance with Bind; the bound pointer is then available through GetBound.
+// Failures are answered by WriteProblem, usually with a 422. It panics when
+// the validate tags of the struct cannot be evaluated, see TagError.
+func MakeValidatedReq(fn http.HandlerFunc, schemaOrStruct interface{}) http.HandlerFunc {
+	validator, isValidator := schemaOrStruct.(ParamsValidator)
+	var structType reflect.Type
+	if !isValidator {
+		structType = reflect.TypeOf(schemaOrStruct)
+		if structType != nil && structType.Kind() == reflect.Ptr {
+			structType = structType.Elem()
+		}
+		if structType == nil || structType.Kind() != reflect.Struct {
+			panic("parameters: MakeValidatedReq needs a ParamsValidator or a struct")
+		}
+		if err := checkTags(structType, make(map[reflect.Type]bool)); err != nil {
+			panic(err.Error())
+		}
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		params, err := DefaultParser.Parse(r)
+		r = r.WithContext(context.WithValue(r.Context(), ParamsKey, params))
+		if err != nil {
+			WriteProblem(rw, r, err)
+			return
+		}
+
+		if isValidator {
+			if err := validator.ValidateParams(params); err != nil {
+				WriteProblem(rw, r, err)
+				return
+			}
+		} else {
+			obj := reflect.New(structType).Interface()
+			if err := params.Bind(obj); err != nil {
+				WriteProblem(rw, r, err)
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), BoundKey, obj))
+		}
+
+		fn(rw, r)
+	}
+}
+
+// GetBound returns the struct bound by MakeValidatedReq, a pointer to the
+// struct type it was given
+func GetBound(req *http.Request) interface{} {
+	return req.Context().Value(BoundKey)
+}
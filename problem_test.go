@@ -0,0 +1,193 @@
+package parameters
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ugorji/go/codec"
+)
+
+func TestWriteProblem(t *testing.T) {
+	r := httptest.NewRequest("POST", "/users", nil)
+	w := httptest.NewRecorder()
+
+	WriteProblem(w, r, ValidationErrors{
+		{Field: "limit", Tag: "min", Param: "1"},
+		{Field: "order", Tag: "oneof", Param: "asc desc"},
+	})
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Error("Expected status 422, got:", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Error("Unexpected content type:", ct)
+	}
+
+	var problem Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatal("Could not decode problem", err)
+	}
+	if problem.Instance != "/users" || len(problem.Errors) != 2 {
+		t.Fatalf("Unexpected problem: %+v", problem)
+	}
+	if e := problem.Errors[0]; e.Field != "limit" || e.Code != "min" || e.Detail != "limit must be at least 1" {
+		t.Errorf("Unexpected error entry: %+v", e)
+	}
+}
+
+func TestWriteProblemMsgpack(t *testing.T) {
+	r := httptest.NewRequest("POST", "/users", nil)
+	r.Header.Set("Content-Type", "application/x-msgpack")
+	w := httptest.NewRecorder()
+
+	WriteProblem(w, r, &TypeError{Key: "id", Type: "uint64"})
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-msgpack" {
+		t.Error("Unexpected content type:", ct)
+	}
+	var mh codec.MsgpackHandle
+	var problem Problem
+	if err := codec.NewDecoder(w.Body, &mh).Decode(&problem); err != nil {
+		t.Fatal("Could not decode problem", err)
+	}
+	if problem.Status != http.StatusUnprocessableEntity || len(problem.Errors) != 1 || problem.Errors[0].Field != "id" {
+		t.Errorf("Unexpected problem: %+v", problem)
+	}
+}
+
+func TestWriteProblemOtherError(t *testing.T) {
+	r := httptest.NewRequest("POST", "/users", nil)
+	w := httptest.NewRecorder()
+
+	WriteProblem(w, r, errors.New("body too large"))
+
+	if w.Code != http.StatusBadRequest {
+		t.Error("Expected status 400, got:", w.Code)
+	}
+}
+
+func TestMakeValidatedReqStruct(t *testing.T) {
+	type query struct {
+		Limit int `validate:"required,max=50"`
+	}
+
+	called := false
+	handler := MakeValidatedReq(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		if q := GetBound(r).(*query); q.Limit != 10 {
+			t.Error("Value of 'limit' should be 10, got:", q.Limit)
+		}
+	}, query{})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/test?limit=10", nil))
+	if !called {
+		t.Fatal("Handler should have been called")
+	}
+
+	called = false
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/test?limit=100", nil))
+	if called || w.Code != http.StatusUnprocessableEntity {
+		t.Error("Expected a 422 without calling the handler, got:", w.Code)
+	}
+}
+
+func TestMakeValidatedReqSchema(t *testing.T) {
+	s := Object(Field("name", String().Required()))
+
+	called := false
+	handler := MakeValidatedReq(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}, s)
+
+	r := httptest.NewRequest("POST", "/test", strings.NewReader(`{"title": "x"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	if called || w.Code != http.StatusUnprocessableEntity {
+		t.Error("Expected a 422 without calling the handler, got:", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"field":"/name"`) {
+		t.Error("Expected the missing field in the body, got:", w.Body.String())
+	}
+}
+
+func TestWriteProblemZeroStatus(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteProblem(w, httptest.NewRequest("GET", "/", nil), &Problem{Detail: "no status"})
+
+	if w.Code != http.StatusInternalServerError {
+		t.Error("Expected status 500, got:", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"status":500`) || !strings.Contains(w.Body.String(), `"title":"Internal Server Error"`) {
+		t.Error("Unexpected body:", w.Body.String())
+	}
+}
+
+func TestMakeValidatedReqParseErrors(t *testing.T) {
+	defer func(saved Parser) { *DefaultParser = saved }(*DefaultParser)
+	DefaultParser.MaxDecompressedSize = 1024
+	DefaultParser.MaxKeys = 2
+
+	type query struct {
+		Name string
+	}
+	called := false
+	handler := MakeValidatedReq(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}, query{})
+
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write(bytes.Repeat([]byte(" "), 4096))
+	gz.Close()
+
+	newRequest := func(contentType string, body io.Reader) *http.Request {
+		r := httptest.NewRequest("POST", "/test", body)
+		r.Header.Set("Content-Type", contentType)
+		return r
+	}
+	tooLarge := newRequest("application/json", &gzipped)
+	tooLarge.Header.Set("Content-Encoding", "gzip")
+
+	cases := []struct {
+		name   string
+		r      *http.Request
+		status int
+	}{
+		{"malformed JSON", newRequest("application/json", strings.NewReader(`{"name": `)), http.StatusBadRequest},
+		{"malformed msgpack", newRequest("application/x-msgpack", bytes.NewReader([]byte{0x92, 0xa1})), http.StatusBadRequest},
+		{"too many keys", newRequest("application/json", strings.NewReader(`{"name": "a", "b": 1, "c": 2}`)), http.StatusBadRequest},
+		{"body too large", tooLarge, http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range cases {
+		called = false
+		w := httptest.NewRecorder()
+		handler(w, tc.r)
+		if called || w.Code != tc.status {
+			t.Errorf("%s: expected a %d without calling the handler, got: %d", tc.name, tc.status, w.Code)
+		}
+	}
+}
+
+func TestMakeValidatedReqInvalidArgument(t *testing.T) {
+	for _, arg := range []interface{}{nil, 42, (*int)(nil)} {
+		func() {
+			defer func() {
+				if msg := recover(); msg != "parameters: MakeValidatedReq needs a ParamsValidator or a struct" {
+					t.Errorf("Unexpected panic for %#v: %v", arg, msg)
+				}
+			}()
+			MakeValidatedReq(func(w http.ResponseWriter, r *http.Request) {}, arg)
+		}()
+	}
+}
@@ -0,0 +1,291 @@
+package parameters
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Mask replaces a sensitive value
+type Mask func(value string) string
+
+// Masks applied by redaction rules
+var (
+	// Filtered replaces the whole value
+	Filtered Mask = func(string) string { return filterReplace[0] }
+
+	// LastFour keeps the last 4 characters only: ************1234
+	LastFour Mask = func(value string) string {
+		if len(value) <= 4 {
+			return strings.Repeat("*", len(value))
+		}
+		return strings.Repeat("*", len(value)-4) + value[len(value)-4:]
+	}
+
+	// MaskEmail keeps the first letter and the domain of an email address:
+	// b***@example.com
+	MaskEmail Mask = func(value string) string {
+		at := strings.LastIndexByte(value, '@')
+		if at < 1 {
+			return filterReplace[0]
+		}
+		return value[:1] + "***" + value[at:]
+	}
+)
+
+type keyRule struct {
+	glob string
+	re   *regexp.Regexp
+	mask Mask
+}
+
+type detector struct {
+	match func(string) bool
+	mask  Mask
+}
+
+// Redactor masks sensitive params before they are logged or reported. Keys
+// are matched, case insensitively, by their name and by their dotted path
+// from the root, so a rule for cvv or payment.cvv masks
+//
+//	{"payment": {"cvv": "123"}}
+//
+// A key matching a rule has its whole value masked, nested values included;
+// the string values of other keys go through the detectors. The keys of
+// FilteredKeys are always filtered.
+type Redactor struct {
+	keys      []keyRule
+	detectors []detector
+}
+
+// NewRedactor returns a redactor without rules
+func NewRedactor() *Redactor {
+	return &Redactor{}
+}
+
+// DefaultRedactor is used to log params, it filters passwords, secrets,
+// tokens, api keys and card security codes, masks card numbers to their last
+// 4 digits and email addresses, and filters JWTs and bearer tokens
+var DefaultRedactor = NewRedactor().
+	Glob("*password*", Filtered).
+	Glob("*secret*", Filtered).
+	Glob("*token*", Filtered).
+	Glob("*api_key*", Filtered).
+	Glob("*cvv*", Filtered).
+	Glob("*cvc*", Filtered).
+	Glob("*card_number*", LastFour).
+	Detect(IsCardNumber, LastFour).
+	Detect(IsJWT, Filtered).
+	Detect(IsBearerToken, Filtered).
+	Detect(isEmail, MaskEmail)
+
+// Glob masks the keys matching a path.Match pattern: *password*
+func (r *Redactor) Glob(pattern string, mask Mask) *Redactor {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic(fmt.Sprintf("parameters: invalid redaction pattern %q: %v", pattern, err))
+	}
+	r.keys = append(r.keys, keyRule{glob: strings.ToLower(pattern), mask: mask})
+	return r
+}
+
+// Regexp masks the keys matching a regular expression, it panics if expr
+// does not compile
+func (r *Redactor) Regexp(expr string, mask Mask) *Redactor {
+	r.keys = append(r.keys, keyRule{re: regexp.MustCompile(expr), mask: mask})
+	return r
+}
+
+// Detect masks the string values for which match returns true
+func (r *Redactor) Detect(match func(value string) bool, mask Mask) *Redactor {
+	r.detectors = append(r.detectors, detector{match, mask})
+	return r
+}
+
+// keyMask returns the mask of the key at keyPath
+func (r *Redactor) keyMask(key, keyPath string) Mask {
+	if contains(FilteredKeys, key) {
+		return Filtered
+	}
+	key, keyPath = strings.ToLower(key), strings.ToLower(keyPath)
+	for _, rule := range r.keys {
+		if rule.re != nil {
+			if rule.re.MatchString(key) || rule.re.MatchString(keyPath) {
+				return rule.mask
+			}
+			continue
+		}
+		if ok, _ := path.Match(rule.glob, key); ok {
+			return rule.mask
+		}
+		if ok, _ := path.Match(rule.glob, keyPath); ok {
+			return rule.mask
+		}
+	}
+	return nil
+}
+
+// Redact returns a copy of values with the sensitive values masked
+func (r *Redactor) Redact(values map[string]interface{}) map[string]interface{} {
+	return r.redactMap("", values)
+}
+
+func (r *Redactor) redactMap(prefix string, values map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		redacted[k] = r.RedactValue(prefix+k, v)
+	}
+	return redacted
+}
+
+// RedactValue masks the value of the key at keyPath, a dotted path
+func (r *Redactor) RedactValue(keyPath string, v interface{}) interface{} {
+	key := keyPath[strings.LastIndexByte(keyPath, '.')+1:]
+	if mask := r.keyMask(key, keyPath); mask != nil {
+		return mask(maskable(v))
+	}
+
+	switch v := v.(type) {
+	case string:
+		for _, d := range r.detectors {
+			if d.match(v) {
+				return d.mask(v)
+			}
+		}
+	case []byte:
+		return r.RedactValue(keyPath, string(v))
+	case map[string]interface{}:
+		return r.redactMap(keyPath+".", v)
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = r.RedactValue(keyPath, item)
+		}
+		return redacted
+	case []string:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = r.RedactValue(keyPath, item)
+		}
+		return redacted
+	}
+	return v
+}
+
+// maskable returns the string masked for a value
+func maskable(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// IsCardNumber reports if s is a payment card number: 13 to 19 digits,
+// optionally grouped by spaces or dashes, passing the Luhn check
+func IsCardNumber(s string) bool {
+	digits := make([]int, 0, 19)
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits = append(digits, int(c-'0'))
+		case c == ' ' || c == '-':
+		default:
+			return false
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	for i := range digits {
+		d := digits[len(digits)-1-i]
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// IsJWT reports if s has the shape of a JSON web token: three base64url
+// segments, the first one being a JSON object
+func IsJWT(s string) bool {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return false
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(header) == 0 || header[0] != '{' {
+		return false
+	}
+	for _, part := range parts[1:] {
+		if _, err := base64.RawURLEncoding.DecodeString(part); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// IsBearerToken reports if s is an authorization header with a bearer token
+func IsBearerToken(s string) bool {
+	return len(s) > 7 && strings.EqualFold(s[:7], "bearer ")
+}
+
+// Flatten returns the params keyed by their dotted path, the items of slices
+// being keyed by their index:
+//
+//	{"user": {"tags": ["a"]}} -> {"user.tags.0": "a"}
+func (p *Params) Flatten() map[string]interface{} {
+	flat := make(map[string]interface{}, len(p.Values))
+	flatten(flat, "", p.Values)
+	return flat
+}
+
+// Flatten returns the params redacted and flattened like Params.Flatten
+func (r *Redactor) Flatten(p *Params) map[string]interface{} {
+	flat := make(map[string]interface{}, len(p.Values))
+	flatten(flat, "", r.Redact(p.Values))
+	return flat
+}
+
+func flatten(flat map[string]interface{}, prefix string, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			flatten(flat, prefix+k+".", item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flatten(flat, prefix+strconv.Itoa(i)+".", item)
+		}
+	default:
+		flat[strings.TrimSuffix(prefix, ".")] = v
+	}
+}
+
+// LogValue logs a field error with its value redacted by DefaultRedactor
+func (e *FieldError) LogValue() slog.Value {
+	keyPath := e.Field
+	if strings.HasPrefix(keyPath, "/") {
+		keyPath = strings.ReplaceAll(keyPath[1:], "/", ".")
+	}
+	return slog.GroupValue(
+		slog.String("field", e.Field),
+		slog.String("tag", e.Tag),
+		slog.String("param", e.Param),
+		slog.Any("value", DefaultRedactor.RedactValue(keyPath, e.Value)),
+	)
+}
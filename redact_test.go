@@ -0,0 +1,104 @@
+package parameters
+
+import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRedactorKeys(t *testing.T) {
+	r := NewRedactor().
+		Glob("*password*", Filtered).
+		Glob("payment.cvv", Filtered).
+		Regexp(`^card_number`, LastFour)
+
+	redacted := r.Redact(map[string]interface{}{
+		"new_password":        "secret",
+		"card_number_confirm": "4111111111111111",
+		"cvv":                 "123",
+		"payment": map[string]interface{}{
+			"cvv":  "456",
+			"card": map[string]interface{}{"card_number": 4111111111111111.0},
+		},
+	})
+
+	expected := map[string]interface{}{
+		"new_password":        "FILTERED",
+		"card_number_confirm": "************1111",
+		"cvv":                 "123",
+		"payment": map[string]interface{}{
+			"cvv":  "FILTERED",
+			"card": map[string]interface{}{"card_number": "************1111"},
+		},
+	}
+	if !reflect.DeepEqual(redacted, expected) {
+		t.Error("Unexpected redaction:", redacted)
+	}
+}
+
+func TestRedactorDetectors(t *testing.T) {
+	cases := map[string]interface{}{
+		"4111 1111 1111 1111":                       "***************1111",
+		"4111 1111 1111 1112":                       "4111 1111 1111 1112",
+		"eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.c2ln": "FILTERED",
+		"Bearer abc.def":                            "FILTERED",
+		"bob@example.com":                           "b***@example.com",
+		"hello world":                               "hello world",
+	}
+	for value, want := range cases {
+		if got := DefaultRedactor.RedactValue("note", value); got != want {
+			t.Errorf("RedactValue(%q) = %v, expected %v", value, got, want)
+		}
+	}
+
+	items := DefaultRedactor.RedactValue("contacts", []interface{}{"bob@example.com", 3.0})
+	if !reflect.DeepEqual(items, []interface{}{"b***@example.com", 3.0}) {
+		t.Error("Unexpected slice redaction:", items)
+	}
+}
+
+func TestRedactorFilteredKeys(t *testing.T) {
+	defer func(keys []string) { FilteredKeys = keys }(FilteredKeys)
+	FilteredKeys = []string{"pin"}
+
+	if v := NewRedactor().RedactValue("account.PIN", 1234.0); v != "FILTERED" {
+		t.Error("Expected FilteredKeys to be filtered, got:", v)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	p := &Params{Values: map[string]interface{}{
+		"name": "bob",
+		"user": map[string]interface{}{
+			"tags":     []interface{}{"a", "b"},
+			"password": "secret",
+		},
+	}}
+
+	expected := map[string]interface{}{
+		"name":          "bob",
+		"user.tags.0":   "a",
+		"user.tags.1":   "b",
+		"user.password": "secret",
+	}
+	if flat := p.Flatten(); !reflect.DeepEqual(flat, expected) {
+		t.Error("Unexpected flattened params:", flat)
+	}
+
+	expected["user.password"] = "FILTERED"
+	if flat := DefaultRedactor.Flatten(p); !reflect.DeepEqual(flat, expected) {
+		t.Error("Unexpected redacted params:", flat)
+	}
+}
+
+func TestFieldErrorLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	logger.Info("invalid", "error", &FieldError{Field: "/payment/card_number", Tag: "len", Param: "16", Value: "41111111111111"})
+
+	if line := buf.String(); !strings.Contains(line, "error.value=**********1111") || !strings.Contains(line, "error.field=/payment/card_number") {
+		t.Error("Unexpected log:", line)
+	}
+}
@@ -0,0 +1,511 @@
+package parameters
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Schema describes the expected shape of a parameter value. Schemas are built
+// with Object, Array, String, Int, Number, Bool and Time:
+//
+//	s := parameters.Object(
+//		parameters.Field("name", parameters.String().Required().MaxLen(80)),
+//		parameters.Field("tags", parameters.Array(parameters.String()).MaxItems(10)),
+//	)
+//	err := s.ValidateParams(params)
+type Schema interface {
+	// conform checks val, records the violations under path and returns val
+	// converted to the canonical type of the schema
+	conform(path string, val interface{}, errs *ValidationErrors) interface{}
+	isRequired() bool
+}
+
+type schemaBase struct {
+	required bool
+}
+
+func (s *schemaBase) isRequired() bool {
+	return s.required
+}
+
+// SchemaField is a named member of an ObjectSchema
+type SchemaField struct {
+	name   string
+	schema Schema
+}
+
+// Field names a schema inside an object
+func Field(name string, schema Schema) SchemaField {
+	return SchemaField{name: name, schema: schema}
+}
+
+// ObjectSchema validates a map of values, the canonical type is
+// map[string]interface{}
+type ObjectSchema struct {
+	schemaBase
+	fields []SchemaField
+	strict bool
+}
+
+// Object returns a schema for an object with the given fields
+func Object(fields ...SchemaField) *ObjectSchema {
+	return &ObjectSchema{fields: fields}
+}
+
+// Required rejects an absent or null value
+func (s *ObjectSchema) Required() *ObjectSchema {
+	s.required = true
+	return s
+}
+
+// Strict rejects keys which are not fields of the object
+func (s *ObjectSchema) Strict() *ObjectSchema {
+	s.strict = true
+	return s
+}
+
+// ValidateParams validates the params against the object schema and converts
+// the values in place to their canonical types, so later getters are exact.
+// Every violation is returned in a ValidationErrors with its field set to a
+// JSON pointer:
+//
+//	/tags/3
+func (s *ObjectSchema) ValidateParams(p *Params) error {
+	if p.Values == nil {
+		p.Values = make(map[string]interface{})
+	}
+	var errs ValidationErrors
+	s.conformMap("", p.Values, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (s *ObjectSchema) conform(path string, val interface{}, errs *ValidationErrors) interface{} {
+	m, ok := val.(map[string]interface{})
+	if str, isStr := val.(string); isStr {
+		ok = json.Unmarshal([]byte(str), &m) == nil && m != nil
+	}
+	if !ok {
+		schemaFail(errs, path, "type", "object", val)
+		return val
+	}
+	s.conformMap(path, m, errs)
+	return m
+}
+
+func (s *ObjectSchema) conformMap(path string, m map[string]interface{}, errs *ValidationErrors) {
+	known := make(map[string]bool, len(s.fields))
+	for _, f := range s.fields {
+		known[f.name] = true
+		val, ok := m[f.name]
+		if !ok || val == nil {
+			if f.schema.isRequired() {
+				schemaFail(errs, path+"/"+escapePointer(f.name), "required", "", nil)
+			}
+			continue
+		}
+		m[f.name] = f.schema.conform(path+"/"+escapePointer(f.name), val, errs)
+	}
+
+	if s.strict {
+		unknown := make([]string, 0)
+		for k := range m {
+			if !known[k] {
+				unknown = append(unknown, k)
+			}
+		}
+		sort.Strings(unknown)
+		for _, k := range unknown {
+			schemaFail(errs, path+"/"+escapePointer(k), "unknown", "", m[k])
+		}
+	}
+}
+
+// ArraySchema validates a list of values, the canonical type is
+// []interface{}. A string is split on commas like GetStringSlice does.
+type ArraySchema struct {
+	schemaBase
+	items    Schema
+	minItems int
+	maxItems int
+}
+
+// Array returns a schema for a list whose elements match items
+func Array(items Schema) *ArraySchema {
+	return &ArraySchema{items: items, minItems: -1, maxItems: -1}
+}
+
+// Required rejects an absent or null value
+func (s *ArraySchema) Required() *ArraySchema {
+	s.required = true
+	return s
+}
+
+// MinItems sets the minimum length of the list
+func (s *ArraySchema) MinItems(n int) *ArraySchema {
+	s.minItems = n
+	return s
+}
+
+// MaxItems sets the maximum length of the list
+func (s *ArraySchema) MaxItems(n int) *ArraySchema {
+	s.maxItems = n
+	return s
+}
+
+func (s *ArraySchema) conform(path string, val interface{}, errs *ValidationErrors) interface{} {
+	items, ok := toSlice(val)
+	if !ok {
+		schemaFail(errs, path, "type", "array", val)
+		return val
+	}
+	if s.minItems >= 0 && len(items) < s.minItems {
+		schemaFail(errs, path, "min", strconv.Itoa(s.minItems), val)
+	}
+	if s.maxItems >= 0 && len(items) > s.maxItems {
+		schemaFail(errs, path, "max", strconv.Itoa(s.maxItems), val)
+	}
+	for i, item := range items {
+		items[i] = s.items.conform(path+"/"+strconv.Itoa(i), item, errs)
+	}
+	return items
+}
+
+// toSlice copies any slice into a []interface{}, splitting strings on commas
+func toSlice(val interface{}) ([]interface{}, bool) {
+	switch v := val.(type) {
+	case []interface{}:
+		return v, true
+	case string:
+		if v == "" {
+			return []interface{}{}, true
+		}
+		raw := strings.Split(v, ",")
+		items := make([]interface{}, len(raw))
+		for i, s := range raw {
+			items[i] = s
+		}
+		return items, true
+	case []byte:
+		return nil, false
+	}
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, true
+}
+
+// StringSchema validates a string value
+type StringSchema struct {
+	schemaBase
+	minLen  int
+	maxLen  int
+	pattern string
+	oneOf   []string
+	email   bool
+}
+
+// String returns a schema for a string
+func String() *StringSchema {
+	return &StringSchema{minLen: -1, maxLen: -1}
+}
+
+// Required rejects an absent or null value
+func (s *StringSchema) Required() *StringSchema {
+	s.required = true
+	return s
+}
+
+// MinLen sets the minimum number of characters
+func (s *StringSchema) MinLen(n int) *StringSchema {
+	s.minLen = n
+	return s
+}
+
+// MaxLen sets the maximum number of characters
+func (s *StringSchema) MaxLen(n int) *StringSchema {
+	s.maxLen = n
+	return s
+}
+
+// Pattern sets a regular expression the string must match
+func (s *StringSchema) Pattern(pattern string) *StringSchema {
+	compileRule(pattern)
+	s.pattern = pattern
+	return s
+}
+
+// OneOf restricts the string to the given values
+func (s *StringSchema) OneOf(values ...string) *StringSchema {
+	s.oneOf = values
+	return s
+}
+
+// Email requires the string to be an email address
+func (s *StringSchema) Email() *StringSchema {
+	s.email = true
+	return s
+}
+
+func (s *StringSchema) conform(path string, val interface{}, errs *ValidationErrors) interface{} {
+	var str string
+	switch v := val.(type) {
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	case bool:
+		// Form values of true and false are converted to booleans when parsed
+		str = strconv.FormatBool(v)
+	default:
+		schemaFail(errs, path, "type", "string", val)
+		return val
+	}
+
+	length := utf8.RuneCountInString(str)
+	if s.minLen >= 0 && length < s.minLen {
+		schemaFail(errs, path, "min", strconv.Itoa(s.minLen), str)
+	}
+	if s.maxLen >= 0 && length > s.maxLen {
+		schemaFail(errs, path, "max", strconv.Itoa(s.maxLen), str)
+	}
+	if s.pattern != "" && !compileRule(s.pattern).MatchString(str) {
+		schemaFail(errs, path, "regex", s.pattern, str)
+	}
+	if s.oneOf != nil && !isOneOf(s.oneOf, str) {
+		schemaFail(errs, path, "oneof", strings.Join(s.oneOf, " "), str)
+	}
+	if s.email && !isEmail(str) {
+		schemaFail(errs, path, "email", "", str)
+	}
+	return str
+}
+
+// IntSchema validates an integer, the canonical type is int64, which keeps
+// the integers above 2^53 a float64 cannot hold
+type IntSchema struct {
+	schemaBase
+	min, max       int64
+	hasMin, hasMax bool
+}
+
+// Int returns a schema for an integer
+func Int() *IntSchema {
+	return &IntSchema{}
+}
+
+// Required rejects an absent or null value
+func (s *IntSchema) Required() *IntSchema {
+	s.required = true
+	return s
+}
+
+// Min sets the minimum value
+func (s *IntSchema) Min(n int64) *IntSchema {
+	s.min, s.hasMin = n, true
+	return s
+}
+
+// Max sets the maximum value
+func (s *IntSchema) Max(n int64) *IntSchema {
+	s.max, s.hasMax = n, true
+	return s
+}
+
+func (s *IntSchema) conform(path string, val interface{}, errs *ValidationErrors) interface{} {
+	n, ok := toInt64(val)
+	if !ok {
+		schemaFail(errs, path, "type", "integer", val)
+		return val
+	}
+	if s.hasMin && n < s.min {
+		schemaFail(errs, path, "min", strconv.FormatInt(s.min, 10), n)
+	}
+	if s.hasMax && n > s.max {
+		schemaFail(errs, path, "max", strconv.FormatInt(s.max, 10), n)
+	}
+	return n
+}
+
+// toInt64 converts integral numbers and numeric strings to an int64
+func toInt64(val interface{}) (int64, bool) {
+	switch v := val.(type) {
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		return n, err == nil
+	case []byte:
+		return toInt64(string(v))
+	case float32:
+		return toInt64(float64(v))
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+// NumberSchema validates a number, the canonical type is float64
+type NumberSchema struct {
+	schemaBase
+	min, max       float64
+	hasMin, hasMax bool
+}
+
+// Number returns a schema for a number
+func Number() *NumberSchema {
+	return &NumberSchema{}
+}
+
+// Required rejects an absent or null value
+func (s *NumberSchema) Required() *NumberSchema {
+	s.required = true
+	return s
+}
+
+// Min sets the minimum value
+func (s *NumberSchema) Min(n float64) *NumberSchema {
+	s.min, s.hasMin = n, true
+	return s
+}
+
+// Max sets the maximum value
+func (s *NumberSchema) Max(n float64) *NumberSchema {
+	s.max, s.hasMax = n, true
+	return s
+}
+
+func (s *NumberSchema) conform(path string, val interface{}, errs *ValidationErrors) interface{} {
+	n, ok := toFloat64(val)
+	if !ok {
+		schemaFail(errs, path, "type", "number", val)
+		return val
+	}
+	if s.hasMin && n < s.min {
+		schemaFail(errs, path, "min", strconv.FormatFloat(s.min, 'g', -1, 64), n)
+	}
+	if s.hasMax && n > s.max {
+		schemaFail(errs, path, "max", strconv.FormatFloat(s.max, 'g', -1, 64), n)
+	}
+	return n
+}
+
+// toFloat64 converts numbers and numeric strings to a float64
+func toFloat64(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	case []byte:
+		return toFloat64(string(v))
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+// BoolSchema validates a boolean, accepting 0 and 1 like GetBoolOk
+type BoolSchema struct {
+	schemaBase
+}
+
+// Bool returns a schema for a boolean
+func Bool() *BoolSchema {
+	return &BoolSchema{}
+}
+
+// Required rejects an absent or null value
+func (s *BoolSchema) Required() *BoolSchema {
+	s.required = true
+	return s
+}
+
+func (s *BoolSchema) conform(path string, val interface{}, errs *ValidationErrors) interface{} {
+	if b, ok := val.(bool); ok {
+		return b
+	}
+	if n, ok := toInt64(val); ok && (n == 0 || n == 1) {
+		return n == 1
+	}
+	schemaFail(errs, path, "type", "boolean", val)
+	return val
+}
+
+// TimeSchema validates a time in one of the formats accepted by GetTimeOk,
+// the canonical type is time.Time
+type TimeSchema struct {
+	schemaBase
+}
+
+// Time returns a schema for a time
+func Time() *TimeSchema {
+	return &TimeSchema{}
+}
+
+// Required rejects an absent or null value
+func (s *TimeSchema) Required() *TimeSchema {
+	s.required = true
+	return s
+}
+
+func (s *TimeSchema) conform(path string, val interface{}, errs *ValidationErrors) interface{} {
+	switch v := val.(type) {
+	case time.Time:
+		return v
+	case string:
+		if t, ok := parseTime(v, time.UTC); ok {
+			return t
+		}
+	}
+	schemaFail(errs, path, "type", "time", val)
+	return val
+}
+
+func isOneOf(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func schemaFail(errs *ValidationErrors, path, tag, param string, val interface{}) {
+	*errs = append(*errs, &FieldError{Field: path, Tag: tag, Param: param, Value: val})
+}
+
+// escapePointer escapes a key to be used as a JSON pointer token
+func escapePointer(key string) string {
+	return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
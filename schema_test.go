@@ -0,0 +1,174 @@
+package parameters
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchemaValidateParams(t *testing.T) {
+	body := `{
+		"name": "Ann",
+		"age": "42",
+		"score": 7,
+		"active": 1,
+		"born": "2016-07-17",
+		"tags": ["a", "b"],
+		"address": {"zip": 12345}
+	}`
+	r, err := http.NewRequest("POST", "test", strings.NewReader(body))
+	if err != nil {
+		t.Fatal("Could not build request", err)
+	}
+	r.Header.Set("Content-Type", "application/json")
+
+	params := ParseParams(r)
+
+	s := Object(
+		Field("name", String().Required().MaxLen(80)),
+		Field("age", Int().Min(0)),
+		Field("score", Number()),
+		Field("active", Bool()),
+		Field("born", Time()),
+		Field("tags", Array(String()).MaxItems(10)),
+		Field("address", Object(Field("zip", Int()))),
+	)
+	if err := s.ValidateParams(params); err != nil {
+		t.Fatal("Expected no errors, got:", err)
+	}
+
+	if age, ok := params.Values["age"].(int64); !ok || age != 42 {
+		t.Errorf("Expected 'age' to be coerced to int64(42), got: %#v", params.Values["age"])
+	}
+	if active, ok := params.Values["active"].(bool); !ok || !active {
+		t.Errorf("Expected 'active' to be coerced to true, got: %#v", params.Values["active"])
+	}
+	born, _ := time.Parse(DateOnly, "2016-07-17")
+	if got, ok := params.Values["born"].(time.Time); !ok || !got.Equal(born) {
+		t.Errorf("Expected 'born' to be coerced to a time, got: %#v", params.Values["born"])
+	}
+	if zip, ok := params.Get("address.zip"); !ok || zip != int64(12345) {
+		t.Errorf("Expected 'address.zip' to be coerced to int64, got: %#v", zip)
+	}
+}
+
+func TestSchemaViolations(t *testing.T) {
+	params := &Params{Values: map[string]interface{}{
+		"age":   "old",
+		"tags":  "a,b,c",
+		"email": "nope",
+		"order": "up",
+		"extra": true,
+		"list":  []interface{}{1.0, "x", 3.5},
+	}}
+
+	s := Object(
+		Field("name", String().Required()),
+		Field("age", Int()),
+		Field("tags", Array(String().MinLen(2)).MaxItems(2)),
+		Field("email", String().Email()),
+		Field("order", String().OneOf("asc", "desc")),
+		Field("list", Array(Int())),
+	).Strict()
+
+	err := s.ValidateParams(params)
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatal("Expected ValidationErrors, got:", err)
+	}
+
+	got := make([]string, len(errs))
+	for i, e := range errs {
+		got[i] = e.Field + ":" + e.Tag
+	}
+	expected := []string{
+		"/name:required",
+		"/age:type",
+		"/tags:max",
+		"/tags/0:min",
+		"/tags/1:min",
+		"/tags/2:min",
+		"/email:email",
+		"/order:oneof",
+		"/list/1:type",
+		"/list/2:type",
+		"/extra:unknown",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+
+	if tags, ok := params.Values["tags"].([]interface{}); !ok || len(tags) != 3 {
+		t.Errorf("Expected 'tags' to be split, got: %#v", params.Values["tags"])
+	}
+}
+
+func TestEscapePointer(t *testing.T) {
+	if escaped := escapePointer("a/b~c"); escaped != "a~1b~0c" {
+		t.Error("Unexpected escaped pointer:", escaped)
+	}
+}
+
+func TestSchemaGettersRoundTrip(t *testing.T) {
+	params := &Params{Values: map[string]interface{}{
+		"n":      "42",
+		"ids":    []interface{}{"1", 2, 3.0},
+		"scores": "1,2,3",
+		"tags":   "a,b",
+		"flag":   1,
+		"score":  "7.5",
+		"born":   "2016-07-17",
+		"big":    "9007199254740993",
+	}}
+	s := Object(
+		Field("n", Int()),
+		Field("big", Int()),
+		Field("ids", Array(Int())),
+		Field("scores", Array(Int())),
+		Field("tags", Array(String())),
+		Field("flag", Bool()),
+		Field("score", Number()),
+		Field("born", Time()),
+	)
+	if err := s.ValidateParams(params); err != nil {
+		t.Fatal("Expected no errors, got:", err)
+	}
+
+	if n := params.GetFloat("n"); n != 42 {
+		t.Error("Unexpected GetFloat:", n)
+	}
+	if n := params.GetInt("n"); n != 42 {
+		t.Error("Unexpected GetInt:", n)
+	}
+	if n := params.GetUint64("n"); n != 42 {
+		t.Error("Unexpected GetUint64:", n)
+	}
+	if big := params.GetInt64("big"); big != 9007199254740993 {
+		t.Error("Expected an integer above 2^53 to keep its precision, got:", big)
+	}
+	if big := params.GetUint64("big"); big != 9007199254740993 {
+		t.Error("Unexpected GetUint64:", big)
+	}
+	for _, key := range []string{"ids", "scores"} {
+		if ids, ok := params.GetIntSliceOk(key); !ok || !reflect.DeepEqual(ids, []int{1, 2, 3}) {
+			t.Errorf("Unexpected GetIntSlice(%q): %v", key, ids)
+		}
+		if ids, ok := params.GetUint64SliceOk(key); !ok || !reflect.DeepEqual(ids, []uint64{1, 2, 3}) {
+			t.Errorf("Unexpected GetUint64Slice(%q): %v", key, ids)
+		}
+		if ids, ok := params.GetFloatSliceOk(key); !ok || !reflect.DeepEqual(ids, []float64{1, 2, 3}) {
+			t.Errorf("Unexpected GetFloatSlice(%q): %v", key, ids)
+		}
+	}
+	if tags, ok := params.GetStringSliceOk("tags"); !ok || !reflect.DeepEqual(tags, []string{"a", "b"}) {
+		t.Error("Unexpected GetStringSlice:", tags)
+	}
+	if !params.GetBool("flag") || params.GetFloat("score") != 7.5 {
+		t.Error("Unexpected values:", params.Values)
+	}
+	if born, ok := params.GetTimeOk("born"); !ok || born.Format(DateOnly) != "2016-07-17" {
+		t.Error("Unexpected GetTime:", born)
+	}
+}
@@ -0,0 +1,171 @@
+package parameters
+
+import "fmt"
+
+// Source is the part of a request a parameter was parsed from
+type Source int
+
+// Sources of the parameters, SourceUnknown is that of the values set directly.
+// SourceBody stands for the body sources, SourceJSON, SourceMsgpack,
+// SourceFile, SourceForm and SourceCSV, in a precedence list or in
+// Params.From.
+const (
+	SourceUnknown Source = iota
+	SourcePath
+	SourceQuery
+	SourceForm
+	SourceJSON
+	SourceMsgpack
+	SourceFile
+	SourceBody
+	SourceHeader
+	SourceCookie
+	SourceCSV
+)
+
+var sourceNames = [...]string{"unknown", "path", "query", "form", "json", "msgpack", "file", "body", "header", "cookie", "csv"}
+
+func (s Source) String() string {
+	if s < 0 || int(s) >= len(sourceNames) {
+		return sourceNames[SourceUnknown]
+	}
+	return sourceNames[s]
+}
+
+// IsBody returns whether the source is the body of the request
+func (s Source) IsBody() bool {
+	switch s {
+	case SourceForm, SourceJSON, SourceMsgpack, SourceFile, SourceBody, SourceCSV:
+		return true
+	}
+	return false
+}
+
+// bodySources are the sources SourceBody stands for, by precedence
+var bodySources = []Source{SourceJSON, SourceMsgpack, SourceFile, SourceForm, SourceCSV}
+
+// DefaultPrecedence is the precedence of the sources when the parser sets
+// none: the path params, then the body, the query string, the headers and
+// the cookies
+var DefaultPrecedence = []Source{SourcePath, SourceBody, SourceQuery, SourceHeader, SourceCookie}
+
+// expandSources replaces SourceBody by the body sources and drops duplicates
+func expandSources(sources []Source) []Source {
+	expanded := make([]Source, 0, len(sources)+len(bodySources))
+	seen := make(map[Source]bool, cap(expanded))
+	for _, source := range sources {
+		group := []Source{source}
+		if source == SourceBody {
+			group = bodySources
+		}
+		for _, s := range group {
+			if !seen[s] {
+				seen[s] = true
+				expanded = append(expanded, s)
+			}
+		}
+	}
+	return expanded
+}
+
+// ConflictError is returned by a strict parser when a key is sent in two
+// sources with different values
+type ConflictError struct {
+	Key     string
+	Sources [2]Source
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("parameter %q has conflicting values in %s and %s", e.Key, e.Sources[0], e.Sources[1])
+}
+
+// setSource records the values parsed from a source, merge sets them
+func (p *Params) setSource(source Source, values map[string]interface{}) {
+	if len(values) == 0 {
+		return
+	}
+	if p.bySource == nil {
+		p.bySource = make(map[Source]map[string]interface{})
+	}
+	p.bySource[source] = values
+}
+
+// order returns the sources merged into Values from the highest precedence
+func (p *Params) order() []Source {
+	if p.precedence == nil {
+		return expandSources(DefaultPrecedence)
+	}
+	return p.precedence
+}
+
+// merge sets the values of the sources by precedence. When strict, it
+// returns a ConflictError for the first key, in lexical order, sent in two
+// sources with different values.
+func (p *Params) merge(strict bool) error {
+	p.Values = make(map[string]interface{})
+	p.sources = make(map[string]Source)
+	var conflict *ConflictError
+	order := p.order()
+	for i := len(order) - 1; i >= 0; i-- {
+		source := order[i]
+		for k, v := range p.bySource[source] {
+			if prev, ok := p.sources[k]; ok && strict && !sameValue(p.Values[k], v) {
+				if conflict == nil || k < conflict.Key {
+					conflict = &ConflictError{Key: k, Sources: [2]Source{source, prev}}
+				}
+			}
+			p.Values[k] = v
+			p.sources[k] = source
+		}
+	}
+	if conflict != nil {
+		return conflict
+	}
+	return nil
+}
+
+// sameValue compares values parsed from different sources, which may differ
+// in type: the path param 5 is a uint64 once coerced, a float64 in a JSON
+// body and a string in a query string
+func sameValue(a, b interface{}) bool {
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// Source returns the source of the value of a top level key, SourceUnknown
+// if it was not parsed from the request
+func (p *Params) Source(key string) Source {
+	return p.sources[key]
+}
+
+// From returns the params parsed from the given sources, by the precedence
+// of the parser. It includes the sources left out of the precedence list.
+func (p *Params) From(sources ...Source) *Params {
+	requested := expandSources(sources)
+	view := &Params{isBinary: p.isBinary, logger: p.logger}
+	for _, source := range expandSources(append(append([]Source{}, p.order()...), requested...)) {
+		for _, s := range requested {
+			if s == source {
+				view.precedence = append(view.precedence, source)
+				view.setSource(source, p.bySource[source])
+			}
+		}
+	}
+	view.merge(false)
+	return view
+}
+
+// FromQuery returns the params parsed from the query string
+func (p *Params) FromQuery() *Params {
+	return p.From(SourceQuery)
+}
+
+// FromBody returns the params parsed from the body: form fields, files, JSON,
+// msgpack or CSV
+func (p *Params) FromBody() *Params {
+	return p.From(SourceBody)
+}
+
+// FromPath returns the path params
+func (p *Params) FromPath() *Params {
+	return p.From(SourcePath)
+}
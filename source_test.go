@@ -0,0 +1,143 @@
+package parameters
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestParamsSource(t *testing.T) {
+	var params *Params
+	mux := http.NewServeMux()
+	mux.Handle("POST /accounts/{id}", ParsedHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params = GetParams(r)
+	})))
+	r := httptest.NewRequest("POST", "/accounts/5?id=6&page=2&sort=name", strings.NewReader(`{"id": 7, "name": "Ann", "page": 3}`))
+	r.Header.Set("Content-Type", "application/json")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+
+	if params == nil {
+		t.Fatal("ServeMux did not match")
+	}
+	sources := map[string]Source{"id": SourcePath, "name": SourceJSON, "page": SourceJSON, "sort": SourceQuery, "missing": SourceUnknown}
+	for key, want := range sources {
+		if got := params.Source(key); got != want {
+			t.Errorf("Expected the source of %s to be %v, got: %v", key, want, got)
+		}
+	}
+	if id := params.GetString("id"); id != "5" {
+		t.Error("Expected the path id, got:", id)
+	}
+	if id := params.FromBody().GetInt("id"); id != 7 {
+		t.Error("Expected the body id, got:", id)
+	}
+	if id := params.FromQuery().GetString("id"); id != "6" {
+		t.Error("Expected the query id, got:", id)
+	}
+	if path := params.FromPath(); len(path.Values) != 1 || path.Source("id") != SourcePath {
+		t.Error("Unexpected path params:", path.Values)
+	}
+	if _, ok := params.FromPath().Get("name"); ok {
+		t.Error("Expected name not to be a path param")
+	}
+}
+
+func TestParamsSourceForm(t *testing.T) {
+	r := httptest.NewRequest("POST", "/?id=1&q=a", strings.NewReader("id=2&ok=true"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	params := ParseParams(r)
+
+	if params.Source("id") != SourceForm || params.GetString("id") != "2" {
+		t.Error("Expected the form body to take precedence, got:", params.Values)
+	}
+	if params.Source("q") != SourceQuery || !params.FromBody().GetBool("ok") {
+		t.Error("Unexpected sources:", params.sources)
+	}
+	if !SourceForm.IsBody() || SourceQuery.IsBody() || SourcePath.String() != "path" {
+		t.Error("Unexpected Source methods")
+	}
+}
+
+func TestParserPrecedence(t *testing.T) {
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest("POST", "/?user_id=5&page=2", strings.NewReader(`{"user_id": 7}`))
+		r.Header.Set("Content-Type", "application/json")
+		return r
+	}
+
+	parser := &Parser{Precedence: []Source{SourcePath, SourceQuery, SourceBody}}
+	params, err := parser.Parse(newRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.GetString("user_id") != "5" || params.Source("user_id") != SourceQuery {
+		t.Error("Expected the query to take precedence, got:", params.Values)
+	}
+
+	parser = &Parser{Precedence: []Source{SourcePath, SourceBody}}
+	params, _ = parser.Parse(newRequest())
+	if _, ok := params.Get("page"); ok || params.GetInt("user_id") != 7 {
+		t.Error("Expected the query to be left out, got:", params.Values)
+	}
+	if params.FromQuery().GetString("page") != "2" {
+		t.Error("Expected the query to remain available, got:", params.FromQuery().Values)
+	}
+}
+
+func TestParserStrict(t *testing.T) {
+	parser := &Parser{Strict: true}
+	r := httptest.NewRequest("POST", "/?user_id=5&page=2", strings.NewReader(`{"user_id": 7, "page": 2}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	_, err := parser.Parse(r)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || conflict.Key != "user_id" || conflict.Sources != [2]Source{SourceJSON, SourceQuery} {
+		t.Fatal("Expected a ConflictError for user_id, got:", err)
+	}
+
+	r = httptest.NewRequest("POST", "/?user_id=7", strings.NewReader(`{"user_id": 7}`))
+	r.Header.Set("Content-Type", "application/json")
+	if _, err := parser.Parse(r); err != nil {
+		t.Error("Expected equal values not to conflict, got:", err)
+	}
+}
+
+func TestParsedReqStrict(t *testing.T) {
+	DefaultParser.Strict = true
+	defer func() { DefaultParser.Strict = false }()
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest("POST", "/users/5?user_id=6", strings.NewReader(`{"user_id": 7}`))
+		r.Header.Set("Content-Type", "application/json")
+		return r
+	}
+	called := false
+	handlers := map[string]http.Handler{
+		"MakeParsedReq": MakeParsedReq(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}),
+		"MakeHTTPRouterParsedReq": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			MakeHTTPRouterParsedReq(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+				called = true
+			})(w, r, httprouter.Params{{Key: "id", Value: "5"}})
+		}),
+	}
+	for name, h := range handlers {
+		called = false
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, newRequest())
+		if called {
+			t.Error(name, "called the handler of a conflicting request")
+		}
+		if w.Code != http.StatusBadRequest || w.Header().Get("Content-Type") != "application/problem+json" {
+			t.Error(name, "expected a 400 problem, got:", w.Code, w.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(w.Body.String(), "user_id") {
+			t.Error(name, "expected the problem to name user_id, got:", w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package parameters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// RecordError is returned by ForEach when a record of a streamed body cannot
+// be decoded or when the handler of a record fails
+type RecordError struct {
+	// Index of the record, from 0
+	Index int
+	Err   error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("parameters: record %d: %v", e.Index, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// ForEachParams calls fn with the params of each record of a streamed body
+// with DefaultParser, see Parser.ForEach
+func ForEachParams(req *http.Request, fn func(*Params) error) error {
+	return DefaultParser.ForEach(req, fn)
+}
+
+// ForEach calls fn with the params of each record of an application/x-ndjson
+// or application/json-seq (RFC 7464) body, decoding one record at a time.
+// Each record must be a JSON object, its numbers are float64 as with Parse
+// and its keys are limited by MaxKeys. The params of a record only hold its
+// values. A compressed body is limited to MaxDecompressedSize once
+// decompressed and each record to MaxRecordSize. ForEach stops on the first
+// record which cannot be decoded or whose handler fails, and returns a
+// RecordError with its index.
+func (ps *Parser) ForEach(req *http.Request, fn func(*Params) error) error {
+	ct := strings.TrimSpace(strings.Split(req.Header.Get("Content-Type"), ";")[0])
+	if ct != "application/x-ndjson" && ct != "application/json-seq" {
+		return fmt.Errorf("parameters: unsupported streaming Content-Type %q", ct)
+	}
+	if req.Body == nil {
+		return nil
+	}
+
+	var r io.Reader = req.Body
+	encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+	if encoding != "" && encoding != "identity" {
+		var err error
+		if r, err = decompressReader(encoding, req.Body); err != nil {
+			return err
+		}
+		r = &limitReader{r: r, n: ps.maxDecompressedSize()}
+	}
+	if ct == "application/json-seq" {
+		r = recordSeparatorReader{r}
+	}
+
+	maxRecord := ps.MaxRecordSize
+	if maxRecord <= 0 {
+		maxRecord = DefaultMaxRecordSize
+	}
+	record := &limitReader{r: r, n: maxRecord}
+	dec := json.NewDecoder(record)
+	for index := 0; ; index++ {
+		var values map[string]interface{}
+		err := dec.Decode(&values)
+		if err == io.EOF {
+			return nil
+		}
+		// The bytes buffered past the record count toward the next one
+		record.n = maxRecord - (record.read - dec.InputOffset())
+		if err == nil && values == nil {
+			err = fmt.Errorf("parameters: record is not a JSON object")
+		}
+		if err == nil {
+			err = ps.checkKeys(values)
+		}
+		if err == nil {
+			params := &Params{logger: ps.Logger}
+			params.setSource(SourceJSON, values)
+			params.merge(false)
+			err = fn(params)
+		}
+		if err != nil {
+			return &RecordError{Index: index, Err: err}
+		}
+	}
+}
+
+// recordSeparatorReader reads a JSON text sequence with the record
+// separators replaced by new lines, for a json.Decoder
+type recordSeparatorReader struct {
+	r io.Reader
+}
+
+func (s recordSeparatorReader) Read(b []byte) (int, error) {
+	n, err := s.r.Read(b)
+	for i := 0; i < n; i++ {
+		if b[i] == 0x1e {
+			b[i] = '\n'
+		}
+	}
+	return n, err
+}
+
+// limitReader reads up to n bytes from r, then fails with ErrBodyTooLarge
+// when more follow
+type limitReader struct {
+	r    io.Reader
+	n    int64
+	read int64
+}
+
+func (l *limitReader) Read(b []byte) (int, error) {
+	if l.n <= 0 {
+		var probe [1]byte
+		if _, err := io.ReadFull(l.r, probe[:]); err != nil {
+			return 0, err
+		}
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(b)) > l.n {
+		b = b[:l.n]
+	}
+	n, err := l.r.Read(b)
+	l.n -= int64(n)
+	l.read += int64(n)
+	return n, err
+}
+
+// checkKeys returns ErrTooManyKeys when values have more keys than the
+// MaxKeys of the parser, counted at any depth
+func (ps *Parser) checkKeys(values map[string]interface{}) error {
+	if ps.MaxKeys > 0 && countKeys(values) > ps.MaxKeys {
+		return ErrTooManyKeys
+	}
+	return nil
+}
+
+func countKeys(v interface{}) int {
+	count := 0
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, value := range v {
+			count += 1 + countKeys(value)
+		}
+	case []interface{}:
+		for _, value := range v {
+			count += countKeys(value)
+		}
+	}
+	return count
+}
@@ -0,0 +1,128 @@
+package parameters
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestForEachParams(t *testing.T) {
+	bodies := map[string]string{
+		"application/x-ndjson": "{\"id\": 1, \"name\": \"Ann\"}\n{\"id\": 2, \"name\": \"Bob\"}\n",
+		"application/json-seq": "\x1e{\"id\": 1, \"name\": \"Ann\"}\n\x1e{\"id\": 2, \"name\": \"Bob\"}\n",
+	}
+	for contentType, body := range bodies {
+		var names []string
+		err := ForEachParams(buildRequest(t, "POST", "/imports", strings.NewReader(body), http.Header{"Content-Type": {contentType}}), func(p *Params) error {
+			if _, ok := p.Values["id"].(float64); !ok {
+				t.Errorf("%s: expected a float64 id, got: %#v", contentType, p.Values["id"])
+			}
+			names = append(names, p.GetString("name"))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(contentType, err)
+		}
+		if strings.Join(names, ",") != "Ann,Bob" {
+			t.Errorf("%s: unexpected records: %v", contentType, names)
+		}
+	}
+}
+
+func TestForEachParamsErrors(t *testing.T) {
+	stop := errors.New("stop")
+	body := "{\"id\": 1}\n{\"id\": 2}\n{\"id\": 3}\n"
+	var count int
+	err := ForEachParams(buildRequest(t, "POST", "/imports", strings.NewReader(body), http.Header{"Content-Type": {"application/x-ndjson"}}), func(p *Params) error {
+		count++
+		if p.GetInt("id") == 2 {
+			return stop
+		}
+		return nil
+	})
+	var recordErr *RecordError
+	if !errors.As(err, &recordErr) || recordErr.Index != 1 || !errors.Is(err, stop) || count != 2 {
+		t.Error("Expected the handler error of record 1, got:", err, count)
+	}
+
+	noop := func(*Params) error { return nil }
+	for _, body := range []string{"{\"id\": 1}\n[1]\n", "{\"id\": 1}\nnull\n", "{\"id\": 1}\n{\"id\":\n"} {
+		err := ForEachParams(buildRequest(t, "POST", "/imports", strings.NewReader(body), http.Header{"Content-Type": {"application/x-ndjson"}}), noop)
+		if !errors.As(err, &recordErr) || recordErr.Index != 1 {
+			t.Errorf("Expected an error for record 1 of %q, got: %v", body, err)
+		}
+	}
+
+	parser := &Parser{MaxKeys: 2}
+	err = parser.ForEach(buildRequest(t, "POST", "/imports", strings.NewReader("{\"a\": 1}\n{\"a\": {\"b\": 1, \"c\": 2}}\n"), http.Header{"Content-Type": {"application/x-ndjson"}}), noop)
+	if !errors.As(err, &recordErr) || recordErr.Index != 1 || !errors.Is(err, ErrTooManyKeys) {
+		t.Error("Expected ErrTooManyKeys for record 1, got:", err)
+	}
+
+	if err := ForEachParams(buildRequest(t, "POST", "/imports", strings.NewReader("{}"), http.Header{"Content-Type": {"application/json"}}), noop); err == nil {
+		t.Error("Expected an error for an unsupported Content-Type")
+	}
+}
+
+func TestForEachParamsGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	for i := 0; i < 1000; i++ {
+		gz.Write([]byte("{\"id\": 1}\n"))
+	}
+	gz.Close()
+
+	r := buildRequest(t, "POST", "/imports", &buf, http.Header{
+		"Content-Type":     {"application/x-ndjson"},
+		"Content-Encoding": {"gzip"},
+	})
+	count := 0
+	if err := ForEachParams(r, func(*Params) error { count++; return nil }); err != nil || count != 1000 {
+		t.Error("Unexpected result:", err, count)
+	}
+}
+
+func TestParseMaxKeys(t *testing.T) {
+	header := http.Header{"Content-Type": {"application/json"}}
+	parser := &Parser{MaxKeys: 3}
+	r := buildRequest(t, "POST", "/users?dry_run=true", strings.NewReader(`{"a": 1, "b": {"c": 1, "d": 2}}`), header)
+	params, err := parser.Parse(r)
+	if !errors.Is(err, ErrTooManyKeys) || len(params.Values) != 0 {
+		t.Error("Expected ErrTooManyKeys and empty params, got:", err, params.Values)
+	}
+	if _, err := parser.Parse(buildRequest(t, "POST", "/users?dry_run=true", strings.NewReader(`{"a": 1, "b": 2}`), header)); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+}
+
+func TestForEachParamsLimits(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	for i := 0; i < 10000; i++ {
+		gz.Write([]byte("{\"id\": 1}\n"))
+	}
+	gz.Close()
+	r := buildRequest(t, "POST", "/imports", bytes.NewReader(buf.Bytes()), http.Header{
+		"Content-Type":     {"application/x-ndjson"},
+		"Content-Encoding": {"gzip"},
+	})
+
+	parser := &Parser{MaxDecompressedSize: 50000}
+	count := 0
+	err := parser.ForEach(r, func(*Params) error { count++; return nil })
+	var recordErr *RecordError
+	if !errors.As(err, &recordErr) || !errors.Is(err, ErrBodyTooLarge) || recordErr.Index != 5000 || count != 5000 {
+		t.Error("Expected ErrBodyTooLarge for a large compressed stream, got:", err, count)
+	}
+
+	parser = &Parser{MaxRecordSize: 64}
+	body := strings.Repeat("{\"id\": 1}\n", 1000) + "{\"name\": \"" + strings.Repeat("x", 100) + "\"}\n"
+	count = 0
+	err = parser.ForEach(buildRequest(t, "POST", "/imports", strings.NewReader(body), http.Header{"Content-Type": {"application/x-ndjson"}}), func(*Params) error { count++; return nil })
+	if !errors.As(err, &recordErr) || recordErr.Index != 1000 || !errors.Is(err, ErrBodyTooLarge) || count != 1000 {
+		t.Error("Expected ErrBodyTooLarge for record 1000, got:", err, count)
+	}
+}
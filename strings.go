@@ -41,7 +41,7 @@ func SnakeToCamelCase(str string, ucFirst bool) string {
 		i = 1
 	}
 
-	for i = i; i < len(words); i++ {
+	for ; i < len(words); i++ {
 		if isKnownAbbreviation(words[i]) {
 			words[i] = strings.ToUpper(words[i])
 		} else {
@@ -0,0 +1,465 @@
+package parameters
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError describes a parameter which failed a validation rule. Field is
+// the parameter name, Tag the rule and Param the argument of the rule:
+//
+//	validate:"min=1" -> Tag: "min", Param: "1"
+type FieldError struct {
+	Field string
+	Tag   string
+	Param string
+	Value interface{}
+}
+
+// Error returns the message of the error in the fallback locale of Messages
+func (e *FieldError) Error() string {
+	return Messages.Localize(e, "")
+}
+
+// ValidationErrors is returned by Bind and Validate and holds every failed
+// rule
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// TagError is returned by Validate and Bind for a validate tag which cannot be
+// evaluated: an unknown rule, a limit which is not a number, an unknown field
+// or an invalid pattern
+type TagError struct {
+	Type  reflect.Type
+	Field string
+	Rule  string
+	Err   error
+}
+
+func (e *TagError) Error() string {
+	return fmt.Sprintf("parameters: invalid %s rule of %s.%s: %v", e.Rule, e.Type, e.Field, e.Err)
+}
+
+func (e *TagError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks the validate tags of the fields of obj, a struct or a
+// pointer to one. The rules are separated by commas, the pattern of a regex
+// rule ending at the next comma followed by a rule:
+//
+//	Limit int    `validate:"required,min=1,max=100"`
+//	Order string `validate:"oneof=asc desc"`
+//	EndAt time.Time `validate:"gtfield=StartAt"`
+//	Code  string `validate:"regex=^[a-z]{2,3}$,len=3"`
+//
+// Without params to bind from, a field counts as present when it is not the
+// zero value. Rules other than required are skipped for absent fields. The
+// tags of a type are checked once, a TagError being returned for a tag which
+// cannot be evaluated.
+func Validate(obj interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	errs, err := validateStruct(v, "", nil)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Bind imbues obj with the params and validates it like Validate, with a field
+// counting as present when its key was found or it has a default tag. Values
+// which cannot be converted are reported with the "type" tag.
+func (p *Params) Bind(obj interface{}) error {
+	found, invalid := p.imbue(obj)
+
+	var errs ValidationErrors
+	skip := make(map[string]bool, len(invalid))
+	sort.Slice(invalid, func(i, j int) bool { return invalid[i].key < invalid[j].key })
+	for _, param := range invalid {
+		errs = append(errs, &FieldError{Field: param.key, Tag: "type", Param: param.field.Type.String(), Value: param.value})
+		skip[CamelToSnakeCase(param.field.Name)] = true
+	}
+
+	v := reflect.ValueOf(obj).Elem()
+	fieldErrs, err := validateStruct(v, "", func(name string) bool { return found[name] })
+	if err != nil {
+		return err
+	}
+	for _, err := range fieldErrs {
+		// Do not report rules for a value which was never set
+		if !skip[err.Field] {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type validationRule struct {
+	tag   string
+	param string
+
+	// limit of min, max and len, pattern of regex, set by check
+	limit   float64
+	pattern *regexp.Regexp
+}
+
+// ruleParams lists the known rules, true for those taking a parameter
+var ruleParams = map[string]bool{
+	"required": false,
+	"email":    false,
+	"min":      true,
+	"max":      true,
+	"len":      true,
+	"oneof":    true,
+	"regex":    true,
+	"eqfield":  true,
+	"nefield":  true,
+	"gtfield":  true,
+	"gtefield": true,
+	"ltfield":  true,
+	"ltefield": true,
+}
+
+// parseRules splits a validate tag into its rules
+func parseRules(tag string) []validationRule {
+	var rules []validationRule
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "regex=") {
+			rule, tag = splitPattern(tag)
+		} else if i := strings.IndexByte(tag, ','); i >= 0 {
+			rule, tag = tag[:i], tag[i+1:]
+		} else {
+			rule, tag = tag, ""
+		}
+		if rule == "" {
+			continue
+		}
+		parts := strings.SplitN(rule, "=", 2)
+		r := validationRule{tag: parts[0]}
+		if len(parts) == 2 {
+			r.param = parts[1]
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// splitPattern splits a tag starting with a regex rule at the first comma
+// followed by a known rule, the pattern keeping the other commas
+func splitPattern(tag string) (rule, rest string) {
+	for i := len("regex="); i < len(tag); i++ {
+		if tag[i] == ',' && startsRule(tag[i+1:]) {
+			return tag[:i], tag[i+1:]
+		}
+	}
+	return tag, ""
+}
+
+// startsRule reports if tag starts with a known rule
+func startsRule(tag string) bool {
+	end := strings.IndexAny(tag, "=,")
+	if end < 0 {
+		end = len(tag)
+	}
+	hasParam, ok := ruleParams[tag[:end]]
+	if !ok {
+		return false
+	}
+	if hasParam {
+		return end < len(tag) && tag[end] == '='
+	}
+	return end == len(tag) || tag[end] == ','
+}
+
+// check parses the parameter of a rule of a field of the struct type t
+func (r *validationRule) check(t reflect.Type) error {
+	if _, ok := ruleParams[r.tag]; !ok {
+		return errors.New("unknown rule")
+	}
+	switch r.tag {
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(r.param, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", r.param)
+		}
+		r.limit = limit
+	case "regex":
+		pattern, err := regexp.Compile(r.param)
+		if err != nil {
+			return err
+		}
+		r.pattern = pattern
+	case "eqfield", "nefield", "gtfield", "gtefield", "ltfield", "ltefield":
+		if _, ok := t.FieldByName(r.param); !ok {
+			return fmt.Errorf("unknown field %q", r.param)
+		}
+	}
+	return nil
+}
+
+type structRules struct {
+	fields [][]validationRule
+	err    error
+}
+
+var typeRules sync.Map
+
+// rulesOf returns the rules of the fields of the struct type t by index,
+// parsed and checked once, or the TagError of the first invalid rule
+func rulesOf(t reflect.Type) ([][]validationRule, error) {
+	if cached, ok := typeRules.Load(t); ok {
+		rules := cached.(*structRules)
+		return rules.fields, rules.err
+	}
+	rules := &structRules{fields: make([][]validationRule, t.NumField())}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		rules.fields[i] = parseRules(field.Tag.Get("validate"))
+		for j := range rules.fields[i] {
+			rule := &rules.fields[i][j]
+			if err := rule.check(t); err != nil && rules.err == nil {
+				rules.err = &TagError{Type: t, Field: field.Name, Rule: rule.tag, Err: err}
+			}
+		}
+	}
+	typeRules.Store(t, rules)
+	return rules.fields, rules.err
+}
+
+// checkTags checks the validate tags of the struct type t and of the structs
+// it embeds or nests
+func checkTags(t reflect.Type, seen map[reflect.Type]bool) error {
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+	if _, err := rulesOf(t); err != nil {
+		return err
+	}
+	for i := 0; i < t.NumField(); i++ {
+		nested := t.Field(i).Type
+		for nested.Kind() == reflect.Ptr {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct && nested != typeOfTime {
+			if err := checkTags(nested, seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateStruct validates the fields of v. present reports if a field was
+// bound, when nil fields which are not zero are present. Nested structs are
+// validated with their fields named parent.child.
+func validateStruct(v reflect.Value, prefix string, present func(name string) bool) (ValidationErrors, error) {
+	t := v.Type()
+	rules, err := rulesOf(t)
+	if err != nil {
+		return nil, err
+	}
+	var errs ValidationErrors
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		if fieldType.PkgPath != "" {
+			continue
+		}
+		field := v.Field(i)
+
+		if fieldType.Anonymous && fieldType.Type.Kind() == reflect.Struct {
+			embedded, err := validateStruct(field, prefix, present)
+			if err != nil {
+				return nil, err
+			}
+			errs = append(errs, embedded...)
+			continue
+		}
+
+		name := prefix + CamelToSnakeCase(fieldType.Name)
+		isPresent := !field.IsZero()
+		if present != nil {
+			isPresent = present(fieldType.Name)
+		}
+
+		for _, rule := range rules[i] {
+			if rule.tag == "required" {
+				if !isPresent {
+					errs = append(errs, &FieldError{Field: name, Tag: rule.tag})
+				}
+				continue
+			}
+			if !isPresent {
+				continue
+			}
+			param, ok := checkRule(v, field, rule)
+			if !ok {
+				errs = append(errs, &FieldError{Field: name, Tag: rule.tag, Param: param, Value: field.Interface()})
+			}
+		}
+
+		if nested := reflect.Indirect(field); isPresent && nested.Kind() == reflect.Struct && nested.Type() != typeOfTime {
+			nestedErrs, err := validateStruct(nested, name+".", nil)
+			if err != nil {
+				return nil, err
+			}
+			errs = append(errs, nestedErrs...)
+		}
+	}
+	return errs, nil
+}
+
+// checkRule evaluates rule, checked by rulesOf, on field, a field of the
+// struct parent, and returns the parameter to report with the rule
+func checkRule(parent, field reflect.Value, rule validationRule) (string, bool) {
+	field = reflect.Indirect(field)
+	switch rule.tag {
+	case "min", "max", "len":
+		size, ok := measure(field)
+		if !ok {
+			return rule.param, false
+		}
+		switch rule.tag {
+		case "min":
+			return rule.param, size >= rule.limit
+		case "max":
+			return rule.param, size <= rule.limit
+		default:
+			return rule.param, size == rule.limit
+		}
+	case "oneof":
+		val := fmt.Sprint(field.Interface())
+		for _, option := range strings.Fields(rule.param) {
+			if option == val {
+				return rule.param, true
+			}
+		}
+		return rule.param, false
+	case "email":
+		if field.Kind() != reflect.String {
+			return "", false
+		}
+		return "", isEmail(field.String())
+	case "regex":
+		if field.Kind() != reflect.String {
+			return rule.param, false
+		}
+		return rule.param, rule.pattern.MatchString(field.String())
+	case "eqfield", "nefield", "gtfield", "gtefield", "ltfield", "ltefield":
+		other := parent.FieldByName(rule.param)
+		param := CamelToSnakeCase(rule.param)
+		cmp, ok := compare(field, reflect.Indirect(other))
+		if !ok {
+			return param, false
+		}
+		switch rule.tag {
+		case "eqfield":
+			return param, cmp == 0
+		case "nefield":
+			return param, cmp != 0
+		case "gtfield":
+			return param, cmp > 0
+		case "gtefield":
+			return param, cmp >= 0
+		case "ltfield":
+			return param, cmp < 0
+		default:
+			return param, cmp <= 0
+		}
+	}
+	return rule.param, false
+}
+
+// isEmail reports if s is a bare email address, without a display name
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+// measure returns the value of numbers and the length of strings, slices and
+// maps
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	}
+	return 0, false
+}
+
+// compare orders two numbers, strings or times
+func compare(a, b reflect.Value) (int, bool) {
+	if !a.IsValid() || !b.IsValid() {
+		return 0, false
+	}
+	if a.Type() == typeOfTime && b.Type() == typeOfTime {
+		at, bt := a.Interface().(time.Time), b.Interface().(time.Time)
+		switch {
+		case at.Before(bt):
+			return -1, true
+		case at.After(bt):
+			return 1, true
+		}
+		return 0, true
+	}
+	if a.Kind() == reflect.String && b.Kind() == reflect.String {
+		return strings.Compare(a.String(), b.String()), true
+	}
+	if a.Kind() == reflect.String || b.Kind() == reflect.String {
+		return 0, false
+	}
+	af, aok := measure(a)
+	bf, bok := measure(b)
+	if !aok || !bok {
+		return 0, false
+	}
+	switch {
+	case af < bf:
+		return -1, true
+	case af > bf:
+		return 1, true
+	}
+	return 0, true
+}
+
+var ruleRegexps sync.Map
+
+// compileRule compiles the pattern of a regex rule once
+func compileRule(pattern string) *regexp.Regexp {
+	if re, ok := ruleRegexps.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(pattern)
+	ruleRegexps.Store(pattern, re)
+	return re
+}
@@ -0,0 +1,172 @@
+package parameters
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type validateTestType struct {
+	Limit   int    `validate:"required,min=1,max=100"`
+	Order   string `validate:"oneof=asc desc"`
+	Email   string `validate:"email"`
+	Code    string `validate:"len=3,regex=^[a-z]+$"`
+	StartAt time.Time
+	EndAt   time.Time `validate:"gtfield=StartAt"`
+}
+
+func TestValidate(t *testing.T) {
+	start := time.Date(2016, 6, 7, 0, 0, 0, 0, time.UTC)
+	valid := validateTestType{
+		Limit:   10,
+		Order:   "asc",
+		Email:   "user@example.com",
+		Code:    "abc",
+		StartAt: start,
+		EndAt:   start.Add(time.Hour),
+	}
+	if err := Validate(&valid); err != nil {
+		t.Fatal("Expected no errors, got:", err)
+	}
+
+	invalid := validateTestType{
+		Order:   "up",
+		Email:   "User <user@example.com>",
+		Code:    "ABCD",
+		StartAt: start,
+		EndAt:   start.Add(-time.Hour),
+	}
+	err := Validate(invalid)
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatal("Expected ValidationErrors, got:", err)
+	}
+
+	got := make([]string, len(errs))
+	for i, e := range errs {
+		got[i] = e.Field + ":" + e.Tag
+	}
+	expected := []string{
+		"limit:required",
+		"order:oneof",
+		"email:email",
+		"code:len",
+		"code:regex",
+		"end_at:gtfield",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+	if msg := errs[5].Error(); msg != "end_at must be greater than start_at" {
+		t.Error("Unexpected message:", msg)
+	}
+}
+
+func TestBind(t *testing.T) {
+	body := "limit=0&order=asc&code=a,b"
+	r, err := http.NewRequest("PUT", "test", strings.NewReader(body))
+	if err != nil {
+		t.Fatal("Could not build request", err)
+	}
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	params := ParseParams(r)
+
+	var obj validateTestType
+	err = params.Bind(&obj)
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatal("Expected ValidationErrors, got:", err)
+	}
+
+	// limit is present so required passes but min fails, code fails the regex
+	// because of the comma
+	got := make([]string, len(errs))
+	for i, e := range errs {
+		got[i] = e.Field + ":" + e.Tag
+	}
+	expected := []string{"limit:min", "code:regex"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestBindTypeError(t *testing.T) {
+	params := &Params{Values: map[string]interface{}{"limit": "many"}}
+
+	var obj validateTestType
+	err := params.Bind(&obj)
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 1 {
+		t.Fatal("Expected one error, got:", err)
+	}
+	if errs[0].Field != "limit" || errs[0].Tag != "type" || errs[0].Param != "int" {
+		t.Errorf("Unexpected error: %+v", errs[0])
+	}
+}
+
+func TestBindDefaultRequired(t *testing.T) {
+	type testType struct {
+		Limit int `default:"25" validate:"required,max=50"`
+	}
+
+	var obj testType
+	if err := (&Params{}).Bind(&obj); err != nil {
+		t.Fatal("Expected no errors, got:", err)
+	}
+	if obj.Limit != 25 {
+		t.Error("Value of 'limit' should be 25, got:", obj.Limit)
+	}
+}
+
+func TestValidateRegexFollowedByRules(t *testing.T) {
+	type code struct {
+		Code string `validate:"regex=^[a-z]+$,len=3"`
+		Pair string `validate:"regex=^[a-z]{1,2}(,[a-z]{1,2})*$,required"`
+	}
+	if err := Validate(code{Code: "abc", Pair: "ab,c"}); err != nil {
+		t.Fatal("Expected no errors, got:", err)
+	}
+
+	errs, ok := Validate(code{Code: "abcd", Pair: "abc"}).(ValidationErrors)
+	if !ok || len(errs) != 2 || errs[0].Tag != "len" || errs[1].Tag != "regex" || errs[1].Param != "^[a-z]{1,2}(,[a-z]{1,2})*$" {
+		t.Fatal("Unexpected errors:", errs)
+	}
+}
+
+func TestValidateInvalidTags(t *testing.T) {
+	type unknownRule struct {
+		Name string `validate:"uppercase"`
+	}
+	type badLimit struct {
+		Limit int `validate:"min=one"`
+	}
+	type unknownField struct {
+		EndAt time.Time `validate:"gtfield=Start"`
+	}
+	type badPattern struct {
+		Code string `validate:"regex=^[a-z+$"`
+	}
+	type nested struct {
+		Inner badLimit
+	}
+	for _, obj := range []interface{}{unknownRule{"a"}, badLimit{1}, unknownField{time.Now()}, badPattern{"a"}, nested{badLimit{1}}} {
+		err := Validate(obj)
+		if tagErr, ok := err.(*TagError); !ok || tagErr.Field == "" || tagErr.Rule == "" {
+			t.Errorf("Expected a TagError for %T, got: %v", obj, err)
+		}
+	}
+
+	var bound badLimit
+	if _, ok := (&Params{Values: map[string]interface{}{"limit": 5}}).Bind(&bound).(*TagError); !ok {
+		t.Error("Expected Bind to return a TagError")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected MakeValidatedReq to panic on an invalid tag")
+		}
+	}()
+	MakeValidatedReq(func(w http.ResponseWriter, r *http.Request) {}, nested{})
+}